@@ -2,13 +2,17 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"reflect"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	hqgologger "github.com/hueristiq/hq-go-logger"
 	hqgologgerformatter "github.com/hueristiq/hq-go-logger/formatter"
@@ -31,9 +35,14 @@ var (
 	listSupportedSources  bool
 	sourcesToUse          []string
 	sourcesToExclude      []string
+	timeout               time.Duration
+	sourceTimeouts        map[string]string
+	aggregateSources      bool
 	outputInJSONL         bool
 	outputFilePath        string
 	outputDirectoryPath   string
+	printStatistics       bool
+	printStatisticsJSON   bool
 	monochrome            bool
 	silent                bool
 	verbose               bool
@@ -49,9 +58,14 @@ func init() {
 	pflag.BoolVar(&listSupportedSources, "sources", false, "")
 	pflag.StringSliceVarP(&sourcesToUse, "sources-to-use", "u", []string{}, "")
 	pflag.StringSliceVarP(&sourcesToExclude, "sources-to-exclude", "e", []string{}, "")
+	pflag.DurationVarP(&timeout, "timeout", "t", 0, "")
+	pflag.StringToStringVar(&sourceTimeouts, "source-timeout", map[string]string{}, "")
+	pflag.BoolVar(&aggregateSources, "aggregate-sources", false, "")
 	pflag.BoolVar(&outputInJSONL, "jsonl", false, "")
 	pflag.StringVarP(&outputFilePath, "output", "o", "", "")
 	pflag.StringVarP(&outputDirectoryPath, "output-directory", "O", "", "")
+	pflag.BoolVar(&printStatistics, "stats", false, "")
+	pflag.BoolVar(&printStatisticsJSON, "stats-json", false, "")
 	pflag.BoolVarP(&monochrome, "monochrome", "m", false, "")
 	pflag.BoolVarP(&silent, "silent", "s", false, "")
 	pflag.BoolVarP(&verbose, "verbose", "v", false, "")
@@ -83,10 +97,17 @@ func init() {
 		h += " -u, --sources-to-use string[]        comma(,) separated sources to use\n"
 		h += " -e, --sources-to-exclude string[]    comma(,) separated sources to exclude\n"
 
+		h += "\nTIMEOUT:\n"
+		h += " -t, --timeout duration               maximum duration of a domain's scan (e.g. 30m)\n"
+		h += "     --source-timeout string[]        comma(,) separated source=duration pairs (e.g. wayback=10m,github=5m)\n"
+
 		h += "\nOUTPUT:\n"
+		h += "     --aggregate-sources bool         record every source that found a URL\n"
 		h += "     --jsonl bool                     output in JSONL(ines)\n"
 		h += " -o, --output string                  output write file path\n"
 		h += " -O, --output-directory string        output write directory path\n"
+		h += "     --stats bool                     print per source statistics table to stderr\n"
+		h += "     --stats-json bool                print per source statistics JSON to stderr\n"
 		h += " -m, --monochrome bool                stdout in monochrome\n"
 		h += " -s, --silent bool                    stdout in silent mode\n"
 		h += " -v, --verbose bool                   stdout in verbose mode\n"
@@ -139,17 +160,10 @@ func main() {
 		hqgologger.Info(fmt.Sprintf("sources marked with %v take in key(s) or token(s).", au.Underline("*").Bold()))
 		hqgologger.Print("")
 
-		needsKey := make(map[string]interface{})
-		keysElem := reflect.ValueOf(&cfg.Keys).Elem()
-
-		for i := range keysElem.NumField() {
-			needsKey[strings.ToLower(keysElem.Type().Field(i).Name)] = keysElem.Field(i).Interface()
-		}
-
 		for index := range cfg.Sources {
 			source := cfg.Sources[index]
 
-			if _, ok := needsKey[source]; ok {
+			if registration, ok := sources.Lookup(source); ok && registration.Keys != sources.KeyUnused {
 				hqgologger.Print("> " + source + " *")
 			} else {
 				hqgologger.Print("> " + source)
@@ -206,20 +220,48 @@ func main() {
 		writer.SetFormatToJSONL()
 	}
 
+	parsedSourceTimeouts := make(map[string]time.Duration, len(sourceTimeouts))
+
+	for source, value := range sourceTimeouts {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			hqgologger.Fatal("failed parsing source timeout!", hqgologger.WithError(err), hqgologger.WithString("source", source))
+		}
+
+		parsedSourceTimeouts[source] = duration
+	}
+
 	finder, err := xurlfind3r.New(&xurlfind3r.Configuration{
 		Client: &xurlfind3r.ClientConfiguration{
 			UserAgent: fmt.Sprintf("%s %s (https://github.com/hueristiq/%s.git)", configuration.NAME, configuration.VERSION, configuration.NAME),
 		},
-		IncludeSubdomains: includeSubdomains,
-		SourcesToUse:      sourcesToUse,
-		SourcesToExclude:  sourcesToExclude,
-		Keys:              cfg.Keys,
+		IncludeSubdomains:   includeSubdomains,
+		SourcesToUse:        sourcesToUse,
+		SourcesToExclude:    sourcesToExclude,
+		Keys:                cfg.Keys,
+		Timeout:             timeout,
+		SourceTimeouts:      parsedSourceTimeouts,
+		AggregateProvenance: aggregateSources,
+		RateLimits:          cfg.RateLimits,
+		Settings:            cfg.Settings,
 	})
 	if err != nil {
 		hqgologger.Fatal("failed creating finder!", hqgologger.WithError(err))
 	}
 
+	if skipped := finder.Skipped(); len(skipped) > 0 {
+		hqgologger.Warn(fmt.Sprintf("skipping %s: no key(s) configured", strings.Join(skipped, ", ")))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	defer stop()
+
 	for index := range domains {
+		if ctx.Err() != nil {
+			break
+		}
+
 		domain := domains[index]
 
 		hqgologger.Info(fmt.Sprintf("Finding URLs for %v...", au.Underline(domain).Bold()))
@@ -248,18 +290,23 @@ func main() {
 			outputs = append(outputs, file)
 		}
 
-		results := finder.Find(domain)
+		results, statistics := finder.FindWithStatistics(ctx, domain)
 
 		for result := range results {
-			for _, output := range outputs {
-				switch result.Type {
-				case sources.ResultError:
-					if verbose {
-						hqgologger.Error("error finding subdomains!", hqgologger.WithError(err), hqgologger.WithString("source", result.Source))
-					}
-				case sources.ResultURL:
+			switch result.Type {
+			case sources.ResultError:
+				var sourceErr *sources.Error
+
+				switch {
+				case errors.As(result.Error, &sourceErr) && isActionable(sourceErr):
+					hqgologger.Warn(sourceErr.Error())
+				case verbose:
+					hqgologger.Error("error finding URLs! "+result.Error.Error(), hqgologger.WithString("source", result.Source))
+				}
+			case sources.ResultURL:
+				for _, output := range outputs {
 					if err := writer.Write(output, domain, result); err != nil {
-						hqgologger.Error("error writing subdomain!", hqgologger.WithError(err), hqgologger.WithString("source", result.Source))
+						hqgologger.Error("error writing URL!", hqgologger.WithError(err), hqgologger.WithString("source", result.Source))
 					}
 				}
 			}
@@ -267,6 +314,31 @@ func main() {
 
 		file.Close()
 
+		if printStatistics {
+			if err := output.WriteStatisticsTable(os.Stderr, statistics); err != nil {
+				hqgologger.Error("error writing statistics!", hqgologger.WithError(err))
+			}
+		}
+
+		if printStatisticsJSON {
+			if err := output.WriteStatisticsJSON(os.Stderr, statistics); err != nil {
+				hqgologger.Error("error writing statistics!", hqgologger.WithError(err))
+			}
+		}
+
 		hqgologger.Print("")
 	}
 }
+
+// isActionable reports whether err is a failure the user can act on (e.g., by fixing or adding
+// keys), and should therefore be reported even outside verbose mode.
+func isActionable(err *sources.Error) bool {
+	switch err.Kind {
+	case sources.ErrorKindAuthFailed, sources.ErrorKindQuotaExhausted, sources.ErrorKindMissingKey, sources.ErrorKindMalformedKey, sources.ErrorKindRateLimited:
+		return true
+	case sources.ErrorKindUnexpected, sources.ErrorKindUpstream, sources.ErrorKindMalformedResponse:
+		return false
+	default:
+		return false
+	}
+}
@@ -3,9 +3,7 @@ module github.com/hueristiq/xurlfind3r
 go 1.24.2
 
 require (
-	dario.cat/mergo v1.0.2
 	github.com/hueristiq/hq-go-http v0.0.0-20250523162446-2894f795aea0
-	github.com/hueristiq/hq-go-limiter v0.0.0-20250515162639-b2464255e416
 	github.com/hueristiq/hq-go-logger v0.0.0-20250608201202-1ee4959bff73
 	github.com/hueristiq/hq-go-url v0.0.0-20250513180855-22cafaf83fb4
 	github.com/logrusorgru/aurora/v4 v4.0.0
@@ -3,8 +3,8 @@ package configuration
 import (
 	"os"
 	"path/filepath"
+	"slices"
 
-	"dario.cat/mergo"
 	hqgologger "github.com/hueristiq/hq-go-logger"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
 	"github.com/logrusorgru/aurora/v4"
@@ -12,9 +12,11 @@ import (
 )
 
 type Configuration struct {
-	Version string       `yaml:"version"`
-	Sources []string     `yaml:"sources"`
-	Keys    sources.Keys `yaml:"keys"`
+	Version    string                      `yaml:"version"`
+	Sources    []string                    `yaml:"sources"`
+	Keys       sources.Keys                `yaml:"keys"`
+	RateLimits map[string]int              `mapstructure:"rate_limits" yaml:"rate_limits"`
+	Settings   map[string]sources.Settings `yaml:"settings,omitempty"`
 }
 
 func (configuration *Configuration) Write(path string) (err error) {
@@ -80,27 +82,49 @@ __  ___   _ _ __| |/ _(_)_ __   __| |___ / _ __
 	}()
 
 	DefaultConfigurationFilePath = filepath.Join(UserDotConfigDirectoryPath, NAME, "config.yaml")
-	DefaultConfiguration         = Configuration{
-		Version: VERSION,
-		Sources: sources.List,
-		Keys: sources.Keys{
-			Bevigil:    []string{},
-			Github:     []string{},
-			IntelX:     []string{},
-			URLScan:    []string{},
-			VirusTotal: []string{},
-		},
-	}
 )
 
+// DefaultConfiguration returns the configuration written on first run. It lists every registered
+// source, an empty key list for each source that takes keys and the requests per minute (per key)
+// of each rate limited source, as declared on registration.
+// It reads the sources registry, so it must be called once every source has registered
+// (i.e., not during package initialization).
+func DefaultConfiguration() (cfg Configuration) {
+	cfg = Configuration{
+		Version:    VERSION,
+		Sources:    []string{},
+		Keys:       sources.Keys{},
+		RateLimits: map[string]int{},
+	}
+
+	for _, registration := range sources.Registrations() {
+		cfg.Sources = append(cfg.Sources, registration.Name)
+
+		if registration.Keys != sources.KeyUnused {
+			cfg.Keys[registration.Name] = sources.SourceKeys{}
+		}
+
+		if registration.RateLimit > 0 {
+			cfg.RateLimits[registration.Name] = registration.RateLimit
+		}
+	}
+
+	return
+}
+
+// CreateUpdate creates the configuration file at path, or updates it if it was written by another
+// version, lists sources other than those registered, or lacks keys for a source that takes them
+// or a rate limit for a rate limited source.
 func CreateUpdate(path string) (err error) {
 	var cfg Configuration
 
+	defaults := DefaultConfiguration()
+
 	_, err = os.Stat(path)
 
 	switch {
 	case err != nil && os.IsNotExist(err):
-		cfg = DefaultConfiguration
+		cfg = defaults
 
 		if err = cfg.Write(path); err != nil {
 			return
@@ -113,13 +137,31 @@ func CreateUpdate(path string) (err error) {
 			return
 		}
 
-		if cfg.Version != VERSION || len(cfg.Sources) != len(sources.List) {
-			if err = mergo.Merge(&cfg, DefaultConfiguration); err != nil {
-				return
+		if cfg.Version != VERSION || !slices.Equal(cfg.Sources, defaults.Sources) || !hasEntries(cfg.Keys, defaults.Keys) || !hasEntries(cfg.RateLimits, defaults.RateLimits) {
+			if cfg.Keys == nil {
+				cfg.Keys = sources.Keys{}
+			}
+
+			if cfg.RateLimits == nil {
+				cfg.RateLimits = map[string]int{}
+			}
+
+			// Only missing entries are added: an empty key list or a zero rate limit (i.e., no limit)
+			// is the user's choice, not a value to fill in.
+			for source, keys := range defaults.Keys {
+				if _, ok := cfg.Keys[source]; !ok {
+					cfg.Keys[source] = keys
+				}
+			}
+
+			for source, rateLimit := range defaults.RateLimits {
+				if _, ok := cfg.RateLimits[source]; !ok {
+					cfg.RateLimits[source] = rateLimit
+				}
 			}
 
 			cfg.Version = VERSION
-			cfg.Sources = sources.List
+			cfg.Sources = defaults.Sources
 
 			if err = cfg.Write(path); err != nil {
 				return
@@ -130,6 +172,17 @@ func CreateUpdate(path string) (err error) {
 	return
 }
 
+// hasEntries reports whether entries has an entry for every source in expected.
+func hasEntries[M ~map[string]V, V any](entries, expected M) bool {
+	for source := range expected {
+		if _, ok := entries[source]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
 func Read(path string) (configuration Configuration, err error) {
 	var file *os.File
 
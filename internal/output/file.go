@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
 )
@@ -89,6 +90,31 @@ func (w *Writer) writeJSON(writer io.Writer, domain string, result sources.Resul
 		Source: result.Source,
 	}
 
+	if result.Provenance != nil {
+		data.Sources = result.Provenance.Sources
+		data.SourceCounts = result.Provenance.Counts
+		data.FirstFound = result.Provenance.FirstFound.UTC().Format(time.RFC3339)
+	}
+
+	if result.Metadata != nil {
+		data.Metadata = &metadataForJSONL{
+			StatusCode: result.Metadata.StatusCode,
+			MIMEType:   result.Metadata.MIMEType,
+			Digest:     result.Metadata.Digest,
+			IP:         result.Metadata.IP,
+			Surface:    result.Metadata.Surface,
+			Captures:   result.Metadata.Captures,
+		}
+
+		if !result.Metadata.FirstSeen.IsZero() {
+			data.Metadata.FirstSeen = result.Metadata.FirstSeen.UTC().Format(time.RFC3339)
+		}
+
+		if !result.Metadata.LastSeen.IsZero() {
+			data.Metadata.LastSeen = result.Metadata.LastSeen.UTC().Format(time.RFC3339)
+		}
+	}
+
 	var dataJSONBytes []byte
 
 	dataJSONBytes, err = json.Marshal(data)
@@ -112,9 +138,24 @@ func (w *Writer) writeJSON(writer io.Writer, domain string, result sources.Resul
 type format string
 
 type resultForJSONL struct {
-	Domain string `json:"domain"`
-	URL    string `json:"url"`
-	Source string `json:"source"`
+	Domain       string            `json:"domain"`
+	URL          string            `json:"url"`
+	Source       string            `json:"source"`
+	Sources      []string          `json:"sources,omitempty"`
+	SourceCounts map[string]int    `json:"source_counts,omitempty"`
+	FirstFound   string            `json:"first_found,omitempty"`
+	Metadata     *metadataForJSONL `json:"metadata,omitempty"`
+}
+
+type metadataForJSONL struct {
+	FirstSeen  string `json:"first_seen,omitempty"`
+	LastSeen   string `json:"last_seen,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	MIMEType   string `json:"mime_type,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	Surface    string `json:"surface,omitempty"`
+	Captures   int    `json:"captures,omitempty"`
 }
 
 const (
@@ -0,0 +1,87 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r"
+)
+
+func WriteStatisticsTable(writer io.Writer, statistics *xurlfind3r.Statistics) (err error) {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "SOURCE\tURLS\tREJECTED\tDUPLICATES\tERRORS\tREQUESTS\tPAGES\tELAPSED\t")
+
+	for _, row := range statisticsRows(statistics) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t\n", row.Source, row.URLs, row.Rejected, row.Duplicates, row.Errors, row.Requests, row.Pages, row.Elapsed)
+	}
+
+	fmt.Fprintf(tw, "%s\t\t\t\t\t\t\t%s\t\n", statistics.Domain, statistics.Elapsed.Round(time.Millisecond))
+
+	err = tw.Flush()
+
+	return
+}
+
+func WriteStatisticsJSON(writer io.Writer, statistics *xurlfind3r.Statistics) (err error) {
+	data := statisticsForJSON{
+		Domain:  statistics.Domain,
+		Elapsed: statistics.Elapsed.Round(time.Millisecond).String(),
+		Sources: statisticsRows(statistics),
+	}
+
+	var dataJSONBytes []byte
+
+	dataJSONBytes, err = json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	_, err = fmt.Fprintln(writer, string(dataJSONBytes))
+
+	return
+}
+
+func statisticsRows(statistics *xurlfind3r.Statistics) (rows []sourceStatisticsForJSON) {
+	rows = make([]sourceStatisticsForJSON, 0, len(statistics.Sources))
+
+	for name, source := range statistics.Sources {
+		rows = append(rows, sourceStatisticsForJSON{
+			Source:     name,
+			URLs:       source.URLs.Load(),
+			Rejected:   source.Rejected.Load(),
+			Duplicates: source.Duplicates.Load(),
+			Errors:     source.Errors.Load(),
+			Requests:   source.Requests.Load(),
+			Pages:      source.Pages.Load(),
+			Elapsed:    source.Elapsed.Round(time.Millisecond).String(),
+		})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Source < rows[j].Source
+	})
+
+	return
+}
+
+type statisticsForJSON struct {
+	Domain  string                    `json:"domain"`
+	Elapsed string                    `json:"elapsed"`
+	Sources []sourceStatisticsForJSON `json:"sources"`
+}
+
+type sourceStatisticsForJSON struct {
+	Source     string `json:"source"`
+	URLs       int64  `json:"urls"`
+	Rejected   int64  `json:"rejected"`
+	Duplicates int64  `json:"duplicates"`
+	Errors     int64  `json:"errors"`
+	Requests   int64  `json:"requests"`
+	Pages      int64  `json:"pages"`
+	Elapsed    string `json:"elapsed"`
+}
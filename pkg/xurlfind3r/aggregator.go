@@ -0,0 +1,149 @@
+package xurlfind3r
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+// aggregator consolidates the URL results of a scan, recording every source that
+// reported each URL rather than only the first one.
+//
+// Fields:
+//   - mutex (sync.Mutex): Guards the fields below against concurrent sources.
+//   - order ([]string): The URLs in order of first report.
+//   - results (map[string]*sources.Result): The consolidated result of each URL.
+type aggregator struct {
+	mutex   sync.Mutex
+	order   []string
+	results map[string]*sources.Result
+}
+
+// Add records a URL result, merging it into the URL's consolidated result.
+//
+// Parameters:
+//   - result (sources.Result): The URL result to record.
+//
+// Returns:
+//   - added (bool): Whether the URL had not been recorded before.
+func (a *aggregator) Add(result sources.Result) (added bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	consolidated, ok := a.results[result.Value]
+	if !ok {
+		consolidated = &sources.Result{
+			Type:   sources.ResultURL,
+			Source: result.Source,
+			Value:  result.Value,
+			Provenance: &sources.Provenance{
+				Sources:    []string{},
+				Counts:     map[string]int{},
+				FirstFound: time.Now(),
+			},
+		}
+
+		a.order = append(a.order, result.Value)
+		a.results[result.Value] = consolidated
+
+		added = true
+	}
+
+	if _, ok = consolidated.Provenance.Counts[result.Source]; !ok {
+		consolidated.Provenance.Sources = append(consolidated.Provenance.Sources, result.Source)
+	}
+
+	consolidated.Provenance.Counts[result.Source]++
+
+	consolidated.Metadata = mergeMetadata(consolidated.Metadata, result.Metadata)
+
+	return
+}
+
+// Results returns the consolidated results, in order of first report.
+//
+// Returns:
+//   - results ([]sources.Result): The consolidated results.
+func (a *aggregator) Results() (results []sources.Result) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	results = make([]sources.Result, 0, len(a.order))
+
+	for _, URL := range a.order {
+		results = append(results, *a.results[URL])
+	}
+
+	return
+}
+
+// mergeMetadata combines the metadata reported by two sources for the same URL.
+// The earliest first-seen and the latest last-seen timestamps and the largest capture
+// count are kept, while other fields keep the first non-zero value.
+//
+// Parameters:
+//   - current (*sources.Metadata): The metadata recorded so far, or nil.
+//   - other (*sources.Metadata): The newly reported metadata, or nil.
+//
+// Returns:
+//   - merged (*sources.Metadata): The combined metadata, or nil if both are nil.
+func mergeMetadata(current, other *sources.Metadata) (merged *sources.Metadata) {
+	switch {
+	case other == nil:
+		return current
+	case current == nil:
+		copied := *other
+
+		return &copied
+	}
+
+	merged = current
+
+	if !other.FirstSeen.IsZero() && (merged.FirstSeen.IsZero() || other.FirstSeen.Before(merged.FirstSeen)) {
+		merged.FirstSeen = other.FirstSeen
+	}
+
+	if other.LastSeen.After(merged.LastSeen) {
+		merged.LastSeen = other.LastSeen
+	}
+
+	if merged.StatusCode == 0 {
+		merged.StatusCode = other.StatusCode
+	}
+
+	if merged.MIMEType == "" {
+		merged.MIMEType = other.MIMEType
+	}
+
+	if merged.Digest == "" {
+		merged.Digest = other.Digest
+	}
+
+	if merged.IP == "" {
+		merged.IP = other.IP
+	}
+
+	if merged.Surface == "" {
+		merged.Surface = other.Surface
+	}
+
+	if other.Captures > merged.Captures {
+		merged.Captures = other.Captures
+	}
+
+	return
+}
+
+// newAggregator creates an empty aggregator.
+//
+// Returns:
+//   - a (*aggregator): A pointer to the initialized aggregator.
+func newAggregator() (a *aggregator) {
+	a = &aggregator{
+		order:   []string{},
+		results: map[string]*sources.Result{},
+	}
+
+	return
+}
@@ -0,0 +1,139 @@
+package xurlfind3r
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	t.Parallel()
+
+	early := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	middle := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		current *sources.Metadata
+		other   *sources.Metadata
+		want    *sources.Metadata
+	}{
+		{
+			name: "both nil",
+		},
+		{
+			name:    "other nil",
+			current: &sources.Metadata{StatusCode: 200},
+			want:    &sources.Metadata{StatusCode: 200},
+		},
+		{
+			name:  "current nil",
+			other: &sources.Metadata{StatusCode: 404},
+			want:  &sources.Metadata{StatusCode: 404},
+		},
+		{
+			name:    "timestamps are widened",
+			current: &sources.Metadata{FirstSeen: middle, LastSeen: middle},
+			other:   &sources.Metadata{FirstSeen: early, LastSeen: late},
+			want:    &sources.Metadata{FirstSeen: early, LastSeen: late},
+		},
+		{
+			name:    "zero timestamps are ignored",
+			current: &sources.Metadata{FirstSeen: middle, LastSeen: middle},
+			other:   &sources.Metadata{},
+			want:    &sources.Metadata{FirstSeen: middle, LastSeen: middle},
+		},
+		{
+			name:    "missing first seen is filled in",
+			current: &sources.Metadata{},
+			other:   &sources.Metadata{FirstSeen: early},
+			want:    &sources.Metadata{FirstSeen: early},
+		},
+		{
+			name:    "first non-zero values are kept",
+			current: &sources.Metadata{StatusCode: 200, MIMEType: "text/html"},
+			other:   &sources.Metadata{StatusCode: 404, MIMEType: "text/plain", Digest: "D", IP: "192.0.2.1", Surface: "code"},
+			want:    &sources.Metadata{StatusCode: 200, MIMEType: "text/html", Digest: "D", IP: "192.0.2.1", Surface: "code"},
+		},
+		{
+			name:    "largest capture count is kept",
+			current: &sources.Metadata{Captures: 3},
+			other:   &sources.Metadata{Captures: 7},
+			want:    &sources.Metadata{Captures: 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			merged := mergeMetadata(tt.current, tt.other)
+
+			if (merged == nil) != (tt.want == nil) {
+				t.Fatalf("mergeMetadata() = %+v, want %+v", merged, tt.want)
+			}
+
+			if merged != nil && *merged != *tt.want {
+				t.Errorf("mergeMetadata() = %+v, want %+v", *merged, *tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeMetadataCopiesOther(t *testing.T) {
+	t.Parallel()
+
+	other := &sources.Metadata{StatusCode: 200}
+
+	merged := mergeMetadata(nil, other)
+
+	merged.StatusCode = 500
+
+	if other.StatusCode != 200 {
+		t.Errorf("mergeMetadata() modified its argument, status code = %d, want 200", other.StatusCode)
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	t.Parallel()
+
+	a := newAggregator()
+
+	reports := []struct {
+		source    string
+		URL       string
+		wantAdded bool
+	}{
+		{source: "wayback", URL: "https://example.com/a", wantAdded: true},
+		{source: "otx", URL: "https://example.com/b", wantAdded: true},
+		{source: "otx", URL: "https://example.com/a", wantAdded: false},
+		{source: "wayback", URL: "https://example.com/a", wantAdded: false},
+	}
+
+	for _, report := range reports {
+		added := a.Add(sources.Result{Type: sources.ResultURL, Source: report.source, Value: report.URL})
+
+		if added != report.wantAdded {
+			t.Errorf("Add(%s from %s) = %v, want %v", report.URL, report.source, added, report.wantAdded)
+		}
+	}
+
+	results := a.Results()
+
+	if len(results) != 2 || results[0].Value != "https://example.com/a" || results[1].Value != "https://example.com/b" {
+		t.Fatalf("Results() = %+v, want a then b", results)
+	}
+
+	provenance := results[0].Provenance
+
+	if !slices.Equal(provenance.Sources, []string{"wayback", "otx"}) {
+		t.Errorf("Results()[0] sources = %v, want [wayback otx]", provenance.Sources)
+	}
+
+	if provenance.Counts["wayback"] != 2 || provenance.Counts["otx"] != 1 {
+		t.Errorf("Results()[0] counts = %v, want wayback 2 and otx 1", provenance.Counts)
+	}
+}
@@ -8,10 +8,10 @@
 package bevigil
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
-	hqgohttp "github.com/hueristiq/hq-go-http"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
 )
 
@@ -33,6 +33,7 @@ type Source struct{}
 // Run initiates the URL discovery process for the specified domain using the Bevigil API.
 //
 // Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
 //   - domain (string): The target domain for which URLs are to be retrieved.
 //   - cfg (*sources.Configuration): The configuration instance containing API keys,
 //     the URL validation function, and any additional settings required by the source.
@@ -41,18 +42,18 @@ type Source struct{}
 //   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
 //     Each result is either a discovered URL (ResultURL) or an error (ResultError)
 //     encountered during the operation.
-func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sources.Result {
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
 
-		key, err := cfg.Keys.Bevigil.PickRandom()
+		key, err := cfg.Keys[source.Name()].PickRandom()
 		if err != nil {
 			result := sources.Result{
 				Type:   sources.ResultError,
 				Source: source.Name(),
-				Error:  err,
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMissingKey, nil, err),
 			}
 
 			results <- result
@@ -61,13 +62,13 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 		}
 
 		getURLsReqURL := fmt.Sprintf("https://osint.bevigil.com/api/%s/urls/", domain)
-		getURLsReqCFG := &hqgohttp.RequestConfiguration{
-			Headers: []hqgohttp.Header{
-				hqgohttp.NewSetHeader("X-Access-Token", key),
+		getURLsReqCFG := &sources.RequestConfiguration{
+			Headers: map[string]string{
+				"X-Access-Token": key,
 			},
 		}
 
-		getURLsRes, err := hqgohttp.Get(getURLsReqURL, getURLsReqCFG)
+		getURLsRes, err := cfg.HTTPClient.Get(ctx, getURLsReqURL, getURLsReqCFG)
 		if err != nil {
 			result := sources.Result{
 				Type:   sources.ResultError,
@@ -86,7 +87,7 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 			result := sources.Result{
 				Type:   sources.ResultError,
 				Source: source.Name(),
-				Error:  err,
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, getURLsRes, err),
 			}
 
 			results <- result
@@ -98,6 +99,8 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 
 		getURLsRes.Body.Close()
 
+		cfg.Statistics.PageFetched()
+
 		for _, URL := range getURLsResData.URLs {
 			var valid bool
 
@@ -126,3 +129,10 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 func (source *Source) Name() (name string) {
 	return sources.BEVIGIL
 }
+
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.BEVIGIL, func() sources.Source {
+		return &Source{}
+	}, sources.KeyRequired, 0)
+}
@@ -4,39 +4,42 @@
 // The Common Crawl index offers archived web data that can be leveraged to discover
 // subdomains or URLs for a given domain by searching historical records. This package
 // defines a Source type that implements the Run and Name methods as specified by the
-// sources.Source interface. The Run method retrieves index metadata, selects relevant
-// indexes based on recent years, queries each index for URL records matching the target
+// sources.Source interface. The Run method retrieves index metadata, selects the indexes
+// to search (by default the newest of each recent year) as configured, queries each index for URL records matching the target
 // domain, validates the returned URLs using a provided function, and streams valid URLs
 // or errors via a channel.
 package commoncrawl
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
+	"net/url"
+	"sync"
 	"time"
 
-	hqgohttp "github.com/hueristiq/hq-go-http"
 	hqgohttpheader "github.com/hueristiq/hq-go-http/header"
+	hqgohttpstatus "github.com/hueristiq/hq-go-http/status"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
 	"github.com/spf13/cast"
 )
 
 // getIndexesResponse represents the structure of the JSON response returned by
-// the Common Crawl index metadata endpoint.
+// the Common Crawl index metadata endpoint: every available index, newest first.
+type getIndexesResponse []getIndexesResponseItem
+
+// getIndexesResponseItem represents a single index listed by the index metadata endpoint.
 //
-// It is defined as a slice of anonymous structs, where each struct contains:
+// It contains the following fields:
 //   - ID: A string identifier for the index.
 //   - Name: The name of the index.
 //   - TimeGate: A URL for time-based redirection.
 //   - CDXAPI: A string containing the API endpoint URL for that index.
 //   - From: A string representing the start date of the index.
 //   - To: A string representing the end date of the index.
-type getIndexesResponse []struct {
+type getIndexesResponseItem struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
 	TimeGate string `json:"timegate"`
@@ -63,10 +66,18 @@ type getPaginationResponse struct {
 //
 // It contains the following fields:
 //   - URL: A string representing a discovered URL.
+//   - Status: The HTTP status code the URL responded with when captured.
+//   - MIME: The MIME type of the URL's content when captured.
+//   - Timestamp: When the URL was captured, in the 20060102150405 layout.
+//   - Digest: A digest of the URL's content when captured.
 //   - Error: A string describing an error encountered for the record, if any.
 type getURLsResponse struct {
-	URL   string `json:"url"`
-	Error string `json:"error"`
+	URL       string `json:"url"`
+	Status    string `json:"status"`
+	MIME      string `json:"mime"`
+	Timestamp string `json:"timestamp"`
+	Digest    string `json:"digest"`
+	Error     string `json:"error"`
 }
 
 // Source represents the Common Crawl data source implementation.
@@ -77,7 +88,15 @@ type Source struct{}
 // Run initiates the process of retrieving URL information from the Common Crawl index
 // for a given domain.
 //
+// Indexes and their pages are fetched concurrently, with at most the "concurrency" setting
+// (5 by default) requests in flight, and at most the "host_concurrency" setting (2 by default)
+// to a single host.
+//
+// URLs are reported along with the status, MIME type, timestamp and digest of their capture.
+// Only captures matching every filter of the "filters" setting (e.g., status:200) are returned.
+//
 // Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
 //   - domain (string): The target domain for which URLs are to be retrieved.
 //   - cfg (*sources.Configuration): The configuration instance containing API keys,
 //     the URL validation function, and any additional settings required by the source.
@@ -86,7 +105,7 @@ type Source struct{}
 //   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
 //     Each result is either a discovered URL (ResultURL) or an error (ResultError)
 //     encountered during the operation.
-func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sources.Result {
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
 	results := make(chan sources.Result)
 
 	go func() {
@@ -94,7 +113,7 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 
 		getIndexesReqURL := "https://index.commoncrawl.org/collinfo.json"
 
-		getIndexesRes, err := hqgohttp.Get(getIndexesReqURL)
+		getIndexesRes, err := cfg.HTTPClient.Get(ctx, getIndexesReqURL)
 		if err != nil {
 			result := sources.Result{
 				Type:   sources.ResultError,
@@ -113,7 +132,7 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 			result := sources.Result{
 				Type:   sources.ResultError,
 				Source: source.Name(),
-				Error:  err,
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, getIndexesRes, err),
 			}
 
 			results <- result
@@ -125,170 +144,340 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 
 		getIndexesRes.Body.Close()
 
-		year := time.Now().Year()
-		years := make([]string, 0)
-		maxYearsBack := 5
+		searchIndexes, err := selectIndexes(getIndexesResData, cfg.Settings[source.Name()])
+		if err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  err,
+			}
 
-		for i := range maxYearsBack {
-			years = append(years, strconv.Itoa(year-i))
+			results <- result
+
+			return
 		}
 
-		searchIndexes := make(map[string]string)
+		concurrency := max(cfg.Settings[source.Name()].Int("concurrency", defaultConcurrency), 1)
+
+		politeness := NewPoliteness(cfg.Settings[source.Name()].Int("host_concurrency", defaultHostConcurrency))
 
-		for _, year := range years {
-			for _, CCIndex := range getIndexesResData {
-				if strings.Contains(CCIndex.ID, year) {
-					if _, ok := searchIndexes[year]; !ok {
-						searchIndexes[year] = CCIndex.CDXAPI
+		filters := cfg.Settings[source.Name()].Strings("filters", nil)
 
-						break
+		slots := make(chan struct{}, concurrency)
+
+		wg := &sync.WaitGroup{}
+
+		for _, CCIndex := range searchIndexes {
+			wg.Add(1)
+
+			CCIndexAPI := withFilters(CCIndex.CDXAPI, filters)
+
+			go func() {
+				defer wg.Done()
+
+				pages, ok := source.pages(ctx, domain, CCIndexAPI, slots, politeness, cfg, results)
+				if !ok {
+					return
+				}
+
+				for page := range pages {
+					select {
+					case slots <- struct{}{}:
+					case <-ctx.Done():
+						return
 					}
+
+					wg.Add(1)
+
+					go func() {
+						defer wg.Done()
+						defer func() { <-slots }()
+
+						source.fetchPage(ctx, domain, CCIndexAPI, page, politeness, cfg, results)
+					}()
 				}
-			}
+			}()
 		}
 
-		for _, CCIndexAPI := range searchIndexes {
-			getPaginationReqCFG := &hqgohttp.RequestConfiguration{
-				Params: map[string]string{
-					"url":          "*." + domain,
-					"output":       "json",
-					"fl":           "url",
-					"showNumPages": "true",
-				},
-				Headers: []hqgohttp.Header{
-					hqgohttp.NewSetHeader(hqgohttpheader.Host.String(), "index.commoncrawl.org"),
-				},
-			}
+		wg.Wait()
+	}()
 
-			getPaginationRes, err := hqgohttp.Get(CCIndexAPI, getPaginationReqCFG)
-			if err != nil {
-				result := sources.Result{
-					Type:   sources.ResultError,
-					Source: source.Name(),
-					Error:  err,
-				}
+	return results
+}
 
-				results <- result
+// pages returns the number of pages of URL records an index holds for the domain.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the request.
+//   - domain (string): The target domain.
+//   - CCIndexAPI (string): The CDX API endpoint of the index.
+//   - slots (chan struct{}): The slots bounding the requests in flight, one of which the request takes.
+//   - politeness (*Politeness): The per-host limits the request is subject to.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream errors to.
+//
+// Returns:
+//   - pages (uint): The number of pages.
+//   - ok (bool): Whether the number of pages was fetched. Errors are reported on results.
+func (source *Source) pages(ctx context.Context, domain, CCIndexAPI string, slots chan struct{}, politeness *Politeness, cfg *sources.Configuration, results chan sources.Result) (pages uint, ok bool) {
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+
+	defer func() { <-slots }()
+
+	getPaginationReqCFG := &sources.RequestConfiguration{
+		Params: map[string]string{
+			"url":          "*." + domain,
+			"output":       "json",
+			"fl":           "url",
+			"showNumPages": "true",
+		},
+		Headers: map[string]string{
+			hqgohttpheader.Host.String(): "index.commoncrawl.org",
+		},
+	}
+
+	getPaginationRes, release, err := source.get(ctx, CCIndexAPI, getPaginationReqCFG, politeness, cfg)
+	if err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  err,
+		}
 
-				continue
-			}
+		results <- result
 
-			var getPaginationData getPaginationResponse
+		return
+	}
 
-			if err = json.NewDecoder(getPaginationRes.Body).Decode(&getPaginationData); err != nil {
-				result := sources.Result{
-					Type:   sources.ResultError,
-					Source: source.Name(),
-					Error:  err,
-				}
+	defer release()
+
+	var getPaginationData getPaginationResponse
 
-				results <- result
+	if err = json.NewDecoder(getPaginationRes.Body).Decode(&getPaginationData); err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, getPaginationRes, err),
+		}
 
-				getPaginationRes.Body.Close()
+		results <- result
 
-				continue
-			}
+		getPaginationRes.Body.Close()
 
-			getPaginationRes.Body.Close()
+		return
+	}
 
-			if getPaginationData.Pages < 1 {
-				continue
+	getPaginationRes.Body.Close()
+
+	pages, ok = getPaginationData.Pages, true
+
+	return
+}
+
+// fetchPage streams the in scope URLs found on a page of an index's URL records for the domain.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the request.
+//   - domain (string): The target domain.
+//   - CCIndexAPI (string): The CDX API endpoint of the index.
+//   - page (uint): The page, starting at 0.
+//   - politeness (*Politeness): The per-host limits the request is subject to.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+func (source *Source) fetchPage(ctx context.Context, domain, CCIndexAPI string, page uint, politeness *Politeness, cfg *sources.Configuration, results chan sources.Result) {
+	getURLsReqCFG := &sources.RequestConfiguration{
+		Params: map[string]string{
+			"url":    "*." + domain,
+			"output": "json",
+			"fl":     "url,status,mime,timestamp,digest",
+			"page":   cast.ToString(page),
+		},
+		Headers: map[string]string{
+			hqgohttpheader.Host.String(): "index.commoncrawl.org",
+		},
+	}
+
+	getURLsRes, release, err := source.get(ctx, CCIndexAPI, getURLsReqCFG, politeness, cfg)
+	if err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  err,
+		}
+
+		results <- result
+
+		return
+	}
+
+	defer release()
+
+	scanner := bufio.NewScanner(getURLsRes.Body)
+
+	for scanner.Scan() {
+		var getURLsResData getURLsResponse
+
+		if err = json.Unmarshal(scanner.Bytes(), &getURLsResData); err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, getURLsRes, err),
 			}
 
-			for page := range getPaginationData.Pages {
-				var getURLsRes *http.Response
-
-				getURLsReqCFG := &hqgohttp.RequestConfiguration{
-					Params: map[string]string{
-						"url":    "*." + domain,
-						"output": "json",
-						"fl":     "url",
-						"page":   cast.ToString(page),
-					},
-					Headers: []hqgohttp.Header{
-						hqgohttp.NewSetHeader(hqgohttpheader.Host.String(), "index.commoncrawl.org"),
-					},
-				}
+			results <- result
 
-				getURLsRes, err = hqgohttp.Get(CCIndexAPI, getURLsReqCFG)
-				if err != nil {
-					result := sources.Result{
-						Type:   sources.ResultError,
-						Source: source.Name(),
-						Error:  err,
-					}
+			continue
+		}
 
-					results <- result
+		if getURLsResData.Error != "" {
+			upstreamErr := sources.NewError(source.Name(), sources.ErrorKindUpstream, getURLsRes, nil)
 
-					continue
-				}
+			upstreamErr.Message = getURLsResData.Error
 
-				scanner := bufio.NewScanner(getURLsRes.Body)
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  upstreamErr,
+			}
 
-				for scanner.Scan() {
-					var getURLsResData getURLsResponse
+			results <- result
 
-					if err = json.Unmarshal(scanner.Bytes(), &getURLsResData); err != nil {
-						result := sources.Result{
-							Type:   sources.ResultError,
-							Source: source.Name(),
-							Error:  err,
-						}
+			continue
+		}
 
-						results <- result
+		var URL string
 
-						continue
-					}
+		var valid bool
 
-					if getURLsResData.Error != "" {
-						result := sources.Result{
-							Type:   sources.ResultError,
-							Source: source.Name(),
-							Error:  fmt.Errorf("%w: %s", errStatic, getURLsResData.Error),
-						}
+		if URL, valid = cfg.Validate(getURLsResData.URL); !valid {
+			continue
+		}
 
-						results <- result
+		metadata := &sources.Metadata{
+			StatusCode: cast.ToInt(getURLsResData.Status),
+			MIMEType:   getURLsResData.MIME,
+			Digest:     getURLsResData.Digest,
+		}
 
-						continue
-					}
+		if timestamp, err := time.Parse(timestampLayout, getURLsResData.Timestamp); err == nil {
+			metadata.FirstSeen = timestamp
+			metadata.LastSeen = timestamp
+		}
 
-					var URL string
+		result := sources.Result{
+			Type:     sources.ResultURL,
+			Source:   source.Name(),
+			Value:    URL,
+			Metadata: metadata,
+		}
 
-					var valid bool
+		results <- result
+	}
 
-					if URL, valid = cfg.Validate(getURLsResData.URL); !valid {
-						continue
-					}
+	if err = scanner.Err(); err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  err,
+		}
 
-					result := sources.Result{
-						Type:   sources.ResultURL,
-						Source: source.Name(),
-						Value:  URL,
-					}
+		results <- result
 
-					results <- result
-				}
+		getURLsRes.Body.Close()
 
-				if err = scanner.Err(); err != nil {
-					result := sources.Result{
-						Type:   sources.ResultError,
-						Source: source.Name(),
-						Error:  err,
-					}
+		return
+	}
 
-					results <- result
+	getURLsRes.Body.Close()
 
-					getURLsRes.Body.Close()
+	cfg.Statistics.PageFetched()
+}
 
-					continue
-				}
+// get performs a GET request to the index server, subject to the per-host limits, retrying it
+// when the server asks to slow down.
+//
+// The index server answers 503 when it is overloaded. On such a response, once the HTTP client's
+// own retries are exhausted, the host is paused for everyone and the request retried, waiting
+// twice as long each time (starting at 5 seconds, up to a minute, or longer if the server asks
+// to), up to the "max_retries" setting (5 by default).
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the request.
+//   - URL (string): The URL to request.
+//   - reqCFG (*sources.RequestConfiguration): The configuration of the request.
+//   - politeness (*Politeness): The per-host limits the request is subject to.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//
+// Returns:
+//   - res (*http.Response): The response.
+//   - release (func()): Releases the request's per-host reservation, once the response is consumed.
+//   - err (error): An error if the request fails.
+func (source *Source) get(ctx context.Context, URL string, reqCFG *sources.RequestConfiguration, politeness *Politeness, cfg *sources.Configuration) (res *http.Response, release func(), err error) {
+	maxRetries := cfg.Settings[source.Name()].Int("max_retries", defaultMaxRetries)
+
+	for attempt := 0; ; attempt++ {
+		release, err = politeness.Acquire(ctx, URL)
+		if err != nil {
+			return
+		}
 
-				getURLsRes.Body.Close()
-			}
+		res, err = cfg.HTTPClient.Get(ctx, URL, reqCFG)
+		if err == nil {
+			return
 		}
-	}()
 
-	return results
+		release()
+
+		var sourceErr *sources.Error
+
+		if !errors.As(err, &sourceErr) || sourceErr.StatusCode != hqgohttpstatus.ServiceUnavailable.Int() || attempt >= maxRetries {
+			return
+		}
+
+		wait := min(slowDownWaitMin<<attempt, slowDownWaitMax)
+
+		wait = max(wait, sourceErr.RetryAfter)
+
+		politeness.Pause(URL, wait)
+	}
+}
+
+// withFilters returns the CDX API endpoint of an index with the provided filters applied
+// (e.g., status:200 or mime:text/html), so that only matching captures are returned.
+//
+// Parameters:
+//   - CCIndexAPI (string): The CDX API endpoint of the index.
+//   - filters ([]string): The filters, in the CDX server's filter syntax.
+//
+// Returns:
+//   - filtered (string): The endpoint with the filters applied.
+func withFilters(CCIndexAPI string, filters []string) (filtered string) {
+	filtered = CCIndexAPI
+
+	if len(filters) == 0 {
+		return
+	}
+
+	parsed, err := url.Parse(CCIndexAPI)
+	if err != nil {
+		return
+	}
+
+	query := parsed.Query()
+
+	for _, filter := range filters {
+		query.Add("filter", filter)
+	}
+
+	parsed.RawQuery = query.Encode()
+
+	filtered = parsed.String()
+
+	return
 }
 
 // Name returns the unique identifier for the data source.
@@ -300,6 +489,25 @@ func (source *Source) Name() (name string) {
 	return sources.COMMONCRAWL
 }
 
-// errStatic is a sentinel error used to prepend error messages when a
-// record-specific error is encountered in the Common Crawl responses.
-var errStatic = errors.New("something went wrong")
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.COMMONCRAWL, func() sources.Source {
+		return &Source{}
+	}, sources.KeyUnused, 0)
+}
+
+const (
+	// timestampLayout is the layout of the capture timestamps returned by the CDX API.
+	timestampLayout = "20060102150405"
+	// defaultConcurrency is the default number of requests made concurrently, across indexes and pages.
+	defaultConcurrency = 5
+	// defaultHostConcurrency is the default number of requests made concurrently to a single host.
+	defaultHostConcurrency = 2
+	// defaultMaxRetries is the default number of times a request is retried when the server asks to slow down.
+	defaultMaxRetries = 5
+	// slowDownWaitMin is the wait before the first retry of a request the server asked to slow down.
+	slowDownWaitMin = 5 * time.Second
+	// slowDownWaitMax is the longest wait between retries of a request the server asked to slow down,
+	// unless it asks for longer.
+	slowDownWaitMax = time.Minute
+)
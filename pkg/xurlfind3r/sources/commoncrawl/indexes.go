@@ -0,0 +1,183 @@
+package commoncrawl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+// selectIndexes picks the indexes to search out of every available index, as listed by the index
+// metadata endpoint (newest first), according to the source's settings:
+//
+//   - index_ids ([]string): Only the indexes with these IDs (e.g., CC-MAIN-2024-10).
+//   - all_indexes (bool): Every index.
+//   - from, to (string): Only the indexes whose crawl overlaps the date range, each bound given
+//     as a year, a month or a day (e.g., 2020, 2020-06 or 2020-06-15) and included in the range.
+//   - latest (int): Only the latest N of the indexes otherwise selected.
+//
+// Settings combine, each narrowing the selection. Without any of them, the newest index of each
+// of the last five years is selected.
+//
+// Parameters:
+//   - indexes (getIndexesResponse): Every available index.
+//   - settings (sources.Settings): The source's settings.
+//
+// Returns:
+//   - selected (getIndexesResponse): The indexes to search.
+//   - err (error): An error if a date is invalid or an index ID is unknown.
+func selectIndexes(indexes getIndexesResponse, settings sources.Settings) (selected getIndexesResponse, err error) {
+	IDs := settings.Strings("index_ids", nil)
+	all := settings.Bool("all_indexes", false)
+	latest := settings.Int("latest", 0)
+
+	var from, to time.Time
+
+	if value := settings.String("from", ""); value != "" {
+		if from, _, err = parseDate(value); err != nil {
+			return
+		}
+	}
+
+	if value := settings.String("to", ""); value != "" {
+		if _, to, err = parseDate(value); err != nil {
+			return
+		}
+	}
+
+	if len(IDs) == 0 && !all && from.IsZero() && to.IsZero() && latest <= 0 {
+		selected = newestPerYear(indexes, defaultYearsBack)
+
+		return
+	}
+
+	for _, ID := range IDs {
+		if !slices.ContainsFunc(indexes, func(index getIndexesResponseItem) bool { return index.ID == ID }) {
+			err = fmt.Errorf("%w: %s", errUnknownIndex, ID)
+
+			return
+		}
+	}
+
+	for _, index := range indexes {
+		if len(IDs) > 0 && !slices.Contains(IDs, index.ID) {
+			continue
+		}
+
+		if !from.IsZero() || !to.IsZero() {
+			indexFrom, fromErr := parseIndexDate(index.From)
+			indexTo, toErr := parseIndexDate(index.To)
+
+			if fromErr != nil || toErr != nil {
+				continue
+			}
+
+			if !from.IsZero() && indexTo.Before(from) {
+				continue
+			}
+
+			if !to.IsZero() && !indexFrom.Before(to) {
+				continue
+			}
+		}
+
+		selected = append(selected, index)
+	}
+
+	if latest > 0 && len(selected) > latest {
+		selected = selected[:latest]
+	}
+
+	return
+}
+
+// newestPerYear selects the newest index of each of the last years.
+//
+// Parameters:
+//   - indexes (getIndexesResponse): Every available index, newest first.
+//   - years (int): The number of years, the current one included.
+//
+// Returns:
+//   - selected (getIndexesResponse): The newest index of each year that has one.
+func newestPerYear(indexes getIndexesResponse, years int) (selected getIndexesResponse) {
+	year := time.Now().Year()
+
+	for i := range years {
+		for _, index := range indexes {
+			if strings.Contains(index.ID, strconv.Itoa(year-i)) {
+				selected = append(selected, index)
+
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// parseDate parses a date given as a year, a month or a day (e.g., 2020, 2020-06 or 2020-06-15)
+// into the period it covers.
+//
+// Parameters:
+//   - value (string): The date.
+//
+// Returns:
+//   - start (time.Time): The start of the period.
+//   - end (time.Time): The end of the period, exclusive.
+//   - err (error): An error if value is not a valid date.
+func parseDate(value string) (start, end time.Time, err error) {
+	layouts := []struct {
+		layout              string
+		years, months, days int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	}
+
+	for _, layout := range layouts {
+		if start, err = time.Parse(layout.layout, value); err == nil {
+			end = start.AddDate(layout.years, layout.months, layout.days)
+
+			return
+		}
+	}
+
+	err = fmt.Errorf("%w: %s", errInvalidDate, value)
+
+	return
+}
+
+// parseIndexDate parses the day of a crawl date reported by the index metadata endpoint
+// (e.g., 2024-02-20T22:47:58), ignoring the time of day.
+//
+// Parameters:
+//   - value (string): The crawl date.
+//
+// Returns:
+//   - date (time.Time): The day of the crawl date.
+//   - err (error): An error if value is not a valid crawl date.
+func parseIndexDate(value string) (date time.Time, err error) {
+	if len(value) > len(time.DateOnly) {
+		value = value[:len(time.DateOnly)]
+	}
+
+	date, err = time.Parse(time.DateOnly, value)
+
+	return
+}
+
+// defaultYearsBack is the number of years searched when no index selection setting is set.
+const defaultYearsBack = 5
+
+var (
+	// errUnknownIndex is returned for index IDs in the "index_ids" setting that match no index.
+	errUnknownIndex = errors.New("unknown Common Crawl index")
+
+	// errInvalidDate is returned for dates in the "from" and "to" settings that cannot be parsed.
+	errInvalidDate = errors.New("invalid date")
+)
@@ -0,0 +1,189 @@
+package commoncrawl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+func TestParseDate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value     string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{
+			value:     "2020",
+			wantStart: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			value:     "2020-06",
+			wantStart: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			value:     "2020-12-31",
+			wantStart: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{value: "2020/06", wantErr: true},
+		{value: "June 2020", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		start, end, err := parseDate(tt.value)
+
+		if tt.wantErr {
+			if !errors.Is(err, errInvalidDate) {
+				t.Errorf("parseDate(%q) error = %v, want %v", tt.value, err, errInvalidDate)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseDate(%q) error = %v, want nil", tt.value, err)
+
+			continue
+		}
+
+		if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+			t.Errorf("parseDate(%q) = %v, %v, want %v, %v", tt.value, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestSelectIndexes(t *testing.T) {
+	t.Parallel()
+
+	// Newest first, as listed by the index metadata endpoint.
+	indexes := getIndexesResponse{
+		{ID: "CC-MAIN-2023-50", From: "2023-11-28T09:47:51", To: "2023-12-12T01:15:43"},
+		{ID: "CC-MAIN-2023-14", From: "2023-03-20T08:34:55", To: "2023-04-02T08:14:41"},
+		{ID: "CC-MAIN-2022-49", From: "2022-11-26T11:14:24", To: "2022-12-10T08:55:32"},
+		{ID: "CC-MAIN-2021-04", From: "2021-01-15T07:34:29", To: "2021-01-28T23:03:08"},
+		{ID: "CC-MAIN-2020-50", From: "2020-11-23T13:38:34", To: "2020-12-06T04:25:03"},
+	}
+
+	tests := []struct {
+		name     string
+		settings sources.Settings
+		want     []string
+		wantErr  error
+	}{
+		{
+			name:     "index IDs",
+			settings: sources.Settings{"index_ids": []string{"CC-MAIN-2022-49", "CC-MAIN-2020-50"}},
+			want:     []string{"CC-MAIN-2022-49", "CC-MAIN-2020-50"},
+		},
+		{
+			name:     "unknown index ID",
+			settings: sources.Settings{"index_ids": []string{"CC-MAIN-1999-01"}},
+			wantErr:  errUnknownIndex,
+		},
+		{
+			name:     "all indexes",
+			settings: sources.Settings{"all_indexes": true},
+			want:     []string{"CC-MAIN-2023-50", "CC-MAIN-2023-14", "CC-MAIN-2022-49", "CC-MAIN-2021-04", "CC-MAIN-2020-50"},
+		},
+		{
+			name:     "from year",
+			settings: sources.Settings{"from": "2023"},
+			want:     []string{"CC-MAIN-2023-50", "CC-MAIN-2023-14"},
+		},
+		{
+			name:     "to month includes indexes overlapping it",
+			settings: sources.Settings{"to": "2021-01"},
+			want:     []string{"CC-MAIN-2021-04", "CC-MAIN-2020-50"},
+		},
+		{
+			name:     "date range",
+			settings: sources.Settings{"from": "2020-12-06", "to": "2022"},
+			want:     []string{"CC-MAIN-2022-49", "CC-MAIN-2021-04", "CC-MAIN-2020-50"},
+		},
+		{
+			name:     "latest narrows other settings",
+			settings: sources.Settings{"from": "2021", "latest": 2},
+			want:     []string{"CC-MAIN-2023-50", "CC-MAIN-2023-14"},
+		},
+		{
+			name:     "latest alone",
+			settings: sources.Settings{"latest": 1},
+			want:     []string{"CC-MAIN-2023-50"},
+		},
+		{
+			name:     "invalid date",
+			settings: sources.Settings{"from": "last year"},
+			wantErr:  errInvalidDate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			selected, err := selectIndexes(indexes, tt.settings)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("selectIndexes() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("selectIndexes() error = %v, want nil", err)
+			}
+
+			if got := indexIDs(selected); !slices.Equal(got, tt.want) {
+				t.Errorf("selectIndexes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectIndexesDefault(t *testing.T) {
+	t.Parallel()
+
+	year := time.Now().Year()
+
+	indexes := getIndexesResponse{
+		{ID: fmt.Sprintf("CC-MAIN-%d-30", year)},
+		{ID: fmt.Sprintf("CC-MAIN-%d-10", year)},
+		{ID: fmt.Sprintf("CC-MAIN-%d-50", year-2)},
+		{ID: fmt.Sprintf("CC-MAIN-%d-40", year-2)},
+		{ID: fmt.Sprintf("CC-MAIN-%d-20", year-defaultYearsBack)},
+	}
+
+	selected, err := selectIndexes(indexes, nil)
+	if err != nil {
+		t.Fatalf("selectIndexes() error = %v, want nil", err)
+	}
+
+	want := []string{
+		fmt.Sprintf("CC-MAIN-%d-30", year),
+		fmt.Sprintf("CC-MAIN-%d-50", year-2),
+	}
+
+	if got := indexIDs(selected); !slices.Equal(got, want) {
+		t.Errorf("selectIndexes() = %v, want %v", got, want)
+	}
+}
+
+// indexIDs returns the IDs of indexes, in order.
+func indexIDs(indexes getIndexesResponse) (IDs []string) {
+	for _, index := range indexes {
+		IDs = append(IDs, index.ID)
+	}
+
+	return
+}
@@ -0,0 +1,138 @@
+package commoncrawl
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Politeness bounds the requests made to each host, safe for concurrent use.
+//
+// At most a fixed number of requests to a host are in flight at once, and a host that asks to
+// slow down (e.g., index.commoncrawl.org's 503 responses) can be paused, holding back every
+// request to it until the pause is over.
+//
+// Fields:
+//   - mutex (sync.Mutex): Guards hosts.
+//   - limit (int): The maximum number of requests in flight per host.
+//   - hosts (map[string]*host): The state of every host requested, keyed by host name.
+type Politeness struct {
+	mutex sync.Mutex
+	limit int
+	hosts map[string]*host
+}
+
+// host tracks the requests made to a single host.
+//
+// Fields:
+//   - slots (chan struct{}): Holds a value for every request in flight.
+//   - pausedUntil (time.Time): The time until which requests must not be made.
+type host struct {
+	slots       chan struct{}
+	pausedUntil time.Time
+}
+
+// Acquire waits until a request to the host of URL may be made, and reserves it.
+//
+// Parameters:
+//   - ctx (context.Context): The context bounding the wait.
+//   - URL (string): The URL about to be requested.
+//
+// Returns:
+//   - release (func()): Releases the reservation, once the response is consumed.
+//   - err (error): The context's error if it is done before the request may be made.
+func (politeness *Politeness) Acquire(ctx context.Context, URL string) (release func(), err error) {
+	h := politeness.host(URL)
+
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		err = ctx.Err()
+
+		return
+	}
+
+	release = func() { <-h.slots }
+
+	for {
+		politeness.mutex.Lock()
+
+		wait := time.Until(h.pausedUntil)
+
+		politeness.mutex.Unlock()
+
+		if wait <= 0 {
+			return
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			release()
+
+			release, err = nil, ctx.Err()
+
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Pause holds back every request to the host of URL for d, unless it is already paused for longer.
+//
+// Parameters:
+//   - URL (string): A URL of the host.
+//   - d (time.Duration): How long to pause the host for.
+func (politeness *Politeness) Pause(URL string, d time.Duration) {
+	h := politeness.host(URL)
+
+	politeness.mutex.Lock()
+	defer politeness.mutex.Unlock()
+
+	if until := time.Now().Add(d); until.After(h.pausedUntil) {
+		h.pausedUntil = until
+	}
+}
+
+// host returns the state of the host of URL, creating it on first use.
+func (politeness *Politeness) host(URL string) (h *host) {
+	name := URL
+
+	if parsed, err := url.Parse(URL); err == nil {
+		name = parsed.Host
+	}
+
+	politeness.mutex.Lock()
+	defer politeness.mutex.Unlock()
+
+	h, ok := politeness.hosts[name]
+	if !ok {
+		h = &host{
+			slots: make(chan struct{}, politeness.limit),
+		}
+
+		politeness.hosts[name] = h
+	}
+
+	return
+}
+
+// NewPoliteness creates a Politeness allowing limit requests in flight per host.
+//
+// Parameters:
+//   - limit (int): The maximum number of requests in flight per host, at least 1.
+//
+// Returns:
+//   - politeness (*Politeness): The new Politeness.
+func NewPoliteness(limit int) (politeness *Politeness) {
+	politeness = &Politeness{
+		limit: max(limit, 1),
+		hosts: map[string]*host{},
+	}
+
+	return
+}
@@ -0,0 +1,244 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	hqgohttpstatus "github.com/hueristiq/hq-go-http/status"
+)
+
+// Error is the error type reported by sources for failures they can classify.
+// It carries enough context for callers to branch on the failure with errors.As,
+// or on its kind with errors.Is and the Err* sentinels (e.g., ErrRateLimited).
+//
+// Fields:
+//   - Source (string): The name of the source that failed.
+//   - Kind (ErrorKind): The classification of the failure.
+//   - StatusCode (int): The HTTP status code of the failed request, or zero if there was none.
+//   - URL (string): The URL of the failed request with keys redacted, or empty if there was none.
+//   - Message (string): The error message reported by the upstream API, if any.
+//   - RetryAfter (time.Duration): How long the upstream API asked to wait before retrying, or zero if it did not.
+//   - Err (error): The underlying error, if any.
+type Error struct {
+	Source     string
+	Kind       ErrorKind
+	StatusCode int
+	URL        string
+	Message    string
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Error returns a short, actionable description of the failure,
+// e.g., "virustotal: key rejected (403)".
+func (e *Error) Error() string {
+	message := e.Source + ": " + e.Kind.String()
+
+	if e.StatusCode > 0 {
+		message += fmt.Sprintf(" (%d)", e.StatusCode)
+	}
+
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+
+	return message
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel error of e's kind.
+func (e *Error) Is(target error) bool {
+	sentinel, ok := kindSentinels[e.Kind]
+
+	return ok && target == sentinel
+}
+
+// ErrorKind classifies the failures reported by sources.
+type ErrorKind int
+
+// Constants representing the kinds of failures reported by sources.
+//
+// List of Constants:
+//   - ErrorKindUnexpected: A failure that fits no other kind (e.g., an unexpected status code).
+//   - ErrorKindRateLimited: The upstream API rejected the request for exceeding its rate limit.
+//   - ErrorKindAuthFailed: The upstream API rejected the key (invalid, revoked or lacking permission).
+//   - ErrorKindQuotaExhausted: The key's quota or credits are used up.
+//   - ErrorKindUpstream: The upstream API failed (e.g., a 5xx status or an error reported in the body).
+//   - ErrorKindMalformedResponse: The response could not be decoded.
+//   - ErrorKindMissingKey: The source needs a key, but none is configured.
+//   - ErrorKindMalformedKey: A configured key is not in the format the source expects.
+//   - ErrorKindTransport: The request failed before a response was received (e.g., a failed dial or TLS handshake).
+const (
+	ErrorKindUnexpected ErrorKind = iota
+	ErrorKindRateLimited
+	ErrorKindAuthFailed
+	ErrorKindQuotaExhausted
+	ErrorKindUpstream
+	ErrorKindMalformedResponse
+	ErrorKindMissingKey
+	ErrorKindMalformedKey
+	ErrorKindTransport
+)
+
+// String returns a short description of the kind.
+func (kind ErrorKind) String() string {
+	switch kind {
+	case ErrorKindRateLimited:
+		return "rate limited"
+	case ErrorKindAuthFailed:
+		return "key rejected"
+	case ErrorKindQuotaExhausted:
+		return "quota exhausted"
+	case ErrorKindUpstream:
+		return "upstream error"
+	case ErrorKindMalformedResponse:
+		return "malformed response"
+	case ErrorKindMissingKey:
+		return "no key configured"
+	case ErrorKindMalformedKey:
+		return "malformed key"
+	case ErrorKindTransport:
+		return "transport error"
+	case ErrorKindUnexpected:
+		return "unexpected error"
+	default:
+		return "unexpected error"
+	}
+}
+
+// NewError creates an Error for the named source.
+//
+// Parameters:
+//   - source (string): The name of the source that failed.
+//   - kind (ErrorKind): The classification of the failure.
+//   - res (*http.Response): The response of the failed request, or nil. Its status code and
+//     (redacted) request URL are recorded on the error.
+//   - err (error): The underlying error, or nil.
+//
+// Returns:
+//   - e (*Error): The new error.
+func NewError(source string, kind ErrorKind, res *http.Response, err error) (e *Error) {
+	e = &Error{
+		Source: source,
+		Kind:   kind,
+		Err:    err,
+	}
+
+	if res != nil {
+		e.StatusCode = res.StatusCode
+
+		if res.Request != nil && res.Request.URL != nil {
+			e.URL = RedactURL(res.Request.URL.String())
+		}
+	}
+
+	return
+}
+
+// ClassifyStatus returns the kind of failure an unsuccessful HTTP status code denotes.
+//
+// Parameters:
+//   - code (int): The HTTP status code.
+//
+// Returns:
+//   - kind (ErrorKind): The kind of failure, ErrorKindUnexpected for codes that fit no other kind.
+func ClassifyStatus(code int) (kind ErrorKind) {
+	switch {
+	case code == hqgohttpstatus.Unauthorized.Int(), code == hqgohttpstatus.Forbidden.Int():
+		kind = ErrorKindAuthFailed
+	case code == hqgohttpstatus.PaymentRequired.Int():
+		kind = ErrorKindQuotaExhausted
+	case code == hqgohttpstatus.TooManyRequests.Int():
+		kind = ErrorKindRateLimited
+	case code >= hqgohttpstatus.InternalServerError.Int():
+		kind = ErrorKindUpstream
+	default:
+		kind = ErrorKindUnexpected
+	}
+
+	return
+}
+
+// RedactURL replaces the values of query parameters and user information that commonly
+// carry API keys (e.g., "apikey", "k", "token") with "REDACTED", so that the URL can be
+// safely logged or reported.
+//
+// Parameters:
+//   - raw (string): The URL to redact.
+//
+// Returns:
+//   - redacted (string): The redacted URL, or raw itself if it cannot be parsed.
+func RedactURL(raw string) (redacted string) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	if parsed.User != nil {
+		parsed.User = url.User("REDACTED")
+	}
+
+	query := parsed.Query()
+
+	for name := range query {
+		if _, ok := sensitiveParams[strings.ToLower(name)]; ok {
+			query.Set(name, "REDACTED")
+		}
+	}
+
+	parsed.RawQuery = query.Encode()
+
+	redacted = parsed.String()
+
+	return
+}
+
+// Sentinel errors matching Error values of the corresponding kind with errors.Is.
+var (
+	ErrUnexpected        = errors.New("unexpected error")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrAuthFailed        = errors.New("key rejected")
+	ErrQuotaExhausted    = errors.New("quota exhausted")
+	ErrUpstream          = errors.New("upstream error")
+	ErrMalformedResponse = errors.New("malformed response")
+	ErrMissingKey        = errors.New("no key configured")
+	ErrMalformedKey      = errors.New("malformed key")
+	ErrTransport         = errors.New("transport error")
+)
+
+var (
+	// kindSentinels maps each kind to its sentinel error.
+	kindSentinels = map[ErrorKind]error{
+		ErrorKindUnexpected:        ErrUnexpected,
+		ErrorKindRateLimited:       ErrRateLimited,
+		ErrorKindAuthFailed:        ErrAuthFailed,
+		ErrorKindQuotaExhausted:    ErrQuotaExhausted,
+		ErrorKindUpstream:          ErrUpstream,
+		ErrorKindMalformedResponse: ErrMalformedResponse,
+		ErrorKindMissingKey:        ErrMissingKey,
+		ErrorKindMalformedKey:      ErrMalformedKey,
+		ErrorKindTransport:         ErrTransport,
+	}
+
+	// sensitiveParams lists the (lowercased) query parameters redacted by RedactURL.
+	sensitiveParams = map[string]struct{}{
+		"access_token": {},
+		"api_key":      {},
+		"apikey":       {},
+		"k":            {},
+		"key":          {},
+		"token":        {},
+	}
+)
@@ -4,51 +4,108 @@
 // The GitHub API allows searching code repositories for occurrences of a given domain,
 // which can reveal URLs or references associated with that domain. This package defines a
 // Source type that implements the Run, Enumerate, and Name methods as specified by the
-// sources.Source interface. The Run method initiates a GitHub code search query for a target
-// domain, and the Enumerate method processes paginated search results, extracts URLs from both
-// raw file content and text matches, and streams discovered URLs or errors via a channel.
+// sources.Source interface. The Run method initiates GitHub code searches for a target domain,
+// and the Enumerate method pages through the search results, splitting searches that match more
+// files than GitHub returns, extracts URLs from both raw file content and text matches, and streams
+// discovered URLs or errors via a channel.
 package github
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
-	"net/url"
+	"strconv"
 	"strings"
-	"time"
+	"sync"
 
-	hqgohttp "github.com/hueristiq/hq-go-http"
 	hqgohttpheader "github.com/hueristiq/hq-go-http/header"
-	hqgohttpstatus "github.com/hueristiq/hq-go-http/status"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
-	"github.com/spf13/cast"
 )
 
 // codeSearchResponse represents the structure of the JSON response returned by the GitHub code search API.
 //
 // It contains the total count of matching records and a slice of items where each item
-// represents a code search result. Each item includes the repository file name, the HTML URL for the file,
-// and any text matches found in the file.
+// represents a code search result.
 type codeSearchResponse struct {
-	TotalCount int `json:"total_count"`
-	Items      []struct {
-		Name        string `json:"name"`
-		HTMLURL     string `json:"html_url"`
-		TextMatches []struct {
-			Fragment string `json:"fragment"`
-		} `json:"text_matches"`
-	} `json:"items"`
+	TotalCount int              `json:"total_count"`
+	Items      []codeSearchItem `json:"items"`
 }
 
-// Source represents the Common Crawl data source implementation.
+// codeSearchItem represents a single code search result. It includes the repository file name,
+// the SHA of the file's blob, the HTML URL for the file, and any text matches found in the file.
+type codeSearchItem struct {
+	Name        string `json:"name"`
+	SHA         string `json:"sha"`
+	HTMLURL     string `json:"html_url"`
+	TextMatches []struct {
+		Fragment string `json:"fragment"`
+	} `json:"text_matches"`
+}
+
+// query is a code search query for a domain, optionally restricted to files whose size
+// falls within a range, which is how queries matching too many files are split.
+//
+// Fields:
+//   - minSize (int): The smallest file size matched, in bytes.
+//   - maxSize (int): The largest file size matched, in bytes, or zero for no size restriction.
+type query struct {
+	minSize int
+	maxSize int
+}
+
+// String returns the search terms of the query for domain.
+func (q query) String(domain string) string {
+	terms := fmt.Sprintf("%q", domain)
+
+	if q.maxSize > 0 {
+		terms += fmt.Sprintf(" size:%d..%d", q.minSize, q.maxSize)
+	}
+
+	return terms
+}
+
+// split divides the query into two queries covering halves of its size range.
+// It fails for queries restricted to a single size.
+func (q query) split() (lower, upper query, ok bool) {
+	if q.maxSize == 0 {
+		q.maxSize = maxIndexedFileSize
+	}
+
+	if q.minSize >= q.maxSize {
+		return
+	}
+
+	middle := q.minSize + (q.maxSize-q.minSize)/2
+
+	lower = query{minSize: q.minSize, maxSize: middle}
+	upper = query{minSize: middle + 1, maxSize: q.maxSize}
+	ok = true
+
+	return
+}
+
+// Source represents the GitHub data source implementation.
 // It implements the sources.Source interface, providing functionality
 // for retrieving URLs by querying GitHub code search results.
-type Source struct{}
+//
+// Fields:
+//   - mutex (sync.Mutex): Guards tokens.
+//   - tokens (*Tokens): The token pool shared by every scan run by the source, created on first use.
+type Source struct {
+	mutex  sync.Mutex
+	tokens *Tokens
+}
 
 // Run initiates the process of retrieving URL information from Github for a given domain.
+// It searches each of the surfaces listed in the "surfaces" setting in turn, only code by default.
 //
 // Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
 //   - domain (string): The target domain for which URLs are to be retrieved.
 //   - cfg (*sources.Configuration): The configuration instance containing API keys,
 //     the URL validation function, and any additional settings required by the source.
@@ -57,57 +114,208 @@ type Source struct{}
 //   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
 //     Each result is either a discovered URL (ResultURL) or an error (ResultError)
 //     encountered during the operation.
-func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sources.Result {
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
 
-		if len(cfg.Keys.Github) == 0 {
+		if len(cfg.Keys[source.Name()]) == 0 {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMissingKey, nil, sources.ErrNoKeys),
+			}
+
+			results <- result
+
 			return
 		}
 
-		tokens := NewTokenManager(cfg.Keys.Github)
+		tokens := source.pool(cfg.Keys[source.Name()])
 
-		searchReqURL := fmt.Sprintf("https://api.github.com/search/code?per_page=100&q=%q&sort=created&order=asc", domain)
+		for _, surface := range cfg.Settings[source.Name()].Strings("surfaces", []string{SurfaceCode}) {
+			if ctx.Err() != nil {
+				return
+			}
 
-		source.Enumerate(searchReqURL, tokens, cfg, results)
+			switch surface {
+			case SurfaceCode:
+				source.Enumerate(ctx, domain, tokens, cfg, results)
+			case SurfaceIssues:
+				source.enumerateIssues(ctx, domain, tokens, cfg, results)
+			case SurfaceCommits:
+				source.enumerateCommits(ctx, domain, tokens, cfg, results)
+			case SurfaceGists:
+				source.enumerateGists(ctx, domain, tokens, cfg, results)
+			default:
+				result := sources.Result{
+					Type:   sources.ResultError,
+					Source: source.Name(),
+					Error:  fmt.Errorf("%w: %s", errUnknownSurface, surface),
+				}
+
+				results <- result
+			}
+		}
 	}()
 
 	return results
 }
 
-// Enumerate processes GitHub code search results by sending HTTP GET requests to the provided search URL,
-// handling pagination via the Link header, and extracting URLs from raw file content and text matches.
+// Enumerate runs GitHub code searches for the domain, page by page, and extracts URLs from the raw
+// content and text matches of every file found.
+//
+// GitHub returns at most 1000 results per search, so a search matching more files is split into
+// searches over halves of its file size range, recursively, until each one fits. Every page is
+// fetched once, and no more than the "max_pages" setting (100 by default, zero meaning no limit)
+// pages are fetched in total. URLs are tagged with the SurfaceCode surface.
+//
+// Files are processed by a pool of "concurrency" workers (5 by default) while the next pages are
+// fetched, and a file whose blob was already processed during the enumeration is skipped.
 //
 // Parameters:
-//   - searchReqURL (string): The URL for the GitHub code search API request.
-//   - tokens (*Tokens): A token manager containing GitHub API tokens to handle rate limiting.
+//   - ctx (context.Context): The context that bounds the lifetime of the enumeration.
+//   - domain (string): The target domain.
+//   - tokens (*Tokens): The token pool used to authenticate searches.
 //   - cfg (*sources.Configuration): The configuration settings used for authentication and regex extraction.
 //   - results (chan sources.Result): A channel to stream discovered URLs or errors.
-func (source *Source) Enumerate(searchReqURL string, tokens *Tokens, cfg *sources.Configuration, results chan sources.Result) {
-	token := tokens.Get()
-
-	if token.RetryAfter > 0 {
-		if len(tokens.pool) == 1 {
-			time.Sleep(time.Duration(token.RetryAfter) * time.Second)
-		} else {
-			token = tokens.Get()
-		}
+func (source *Source) Enumerate(ctx context.Context, domain string, tokens *Tokens, cfg *sources.Configuration, results chan sources.Result) {
+	maxPages := cfg.Settings[source.Name()].Int("max_pages", defaultMaxPages)
+
+	concurrency := max(cfg.Settings[source.Name()].Int("concurrency", defaultConcurrency), 1)
+
+	items := make(chan codeSearchItem)
+
+	wg := &sync.WaitGroup{}
+
+	for range concurrency {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for item := range items {
+				source.extract(ctx, item, cfg, results)
+			}
+		}()
 	}
 
-	codeSearchResCFG := &hqgohttp.RequestConfiguration{
-		Headers: []hqgohttp.Header{
-			hqgohttp.NewSetHeader(hqgohttpheader.Accept.String(), "application/vnd.github.v3.text-match+json"),
-			hqgohttp.NewSetHeader(hqgohttpheader.Authorization.String(), "token "+token.Hash),
-		},
+	defer func() {
+		close(items)
+
+		wg.Wait()
+	}()
+
+	seenBlobs := map[string]struct{}{}
+
+	fetched := 0
+
+	queue := []query{{}}
+
+	for len(queue) > 0 {
+		q := queue[0]
+		queue = queue[1:]
+
+		for page := 1; page <= searchResultsCap/perPage; page++ {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if maxPages > 0 && fetched >= maxPages {
+				return
+			}
+
+			var codeSearchResData codeSearchResponse
+
+			if !source.fetch(ctx, "https://api.github.com/search/code", searchParams(q.String(domain), page), tokens, cfg, results, &codeSearchResData) {
+				return
+			}
+
+			fetched++
+
+			if page == 1 && codeSearchResData.TotalCount > searchResultsCap {
+				if lower, upper, splittable := q.split(); splittable {
+					queue = append(queue, lower, upper)
+
+					break
+				}
+			}
+
+			for _, item := range codeSearchResData.Items {
+				if _, seen := seenBlobs[item.SHA]; seen && item.SHA != "" {
+					continue
+				}
+
+				seenBlobs[item.SHA] = struct{}{}
+
+				select {
+				case items <- item:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if len(codeSearchResData.Items) < perPage || page*perPage >= codeSearchResData.TotalCount {
+				break
+			}
+		}
 	}
+}
 
-	codeSearchRes, err := hqgohttp.Get(searchReqURL, codeSearchResCFG)
+// fetch performs an authenticated GET request to the GitHub API and decodes the response into v,
+// switching tokens whenever one hits a rate limit.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the request.
+//   - URL (string): The API endpoint.
+//   - params (map[string]string): The query parameters of the request.
+//   - tokens (*Tokens): The token pool used to authenticate the request.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream errors to.
+//   - v (interface{}): The value to decode the response into.
+//
+// Returns:
+//   - ok (bool): Whether the response was fetched and decoded. Errors are reported on results.
+func (source *Source) fetch(ctx context.Context, URL string, params map[string]string, tokens *Tokens, cfg *sources.Configuration, results chan sources.Result, v interface{}) (ok bool) {
+	var (
+		res *http.Response
+		err error
+	)
+
+	for {
+		var token string
+
+		token, err = tokens.Acquire(ctx)
+		if err != nil {
+			return
+		}
+
+		reqCFG := &sources.RequestConfiguration{
+			Params: params,
+			Headers: map[string]string{
+				hqgohttpheader.Accept.String():        "application/vnd.github.v3.text-match+json",
+				hqgohttpheader.Authorization.String(): "token " + token,
+			},
+			HandleRateLimits: true,
+		}
+
+		res, err = cfg.HTTPClient.Get(ctx, URL, reqCFG)
+
+		tokens.Update(token, res)
 
-	isForbidden := codeSearchRes != nil && codeSearchRes.StatusCode == hqgohttpstatus.Forbidden.Int()
+		var sourceErr *sources.Error
 
-	if err != nil && !isForbidden {
+		if errors.As(err, &sourceErr) && sourceErr.Kind == sources.ErrorKindRateLimited {
+			tokens.Exceeded(token, sourceErr.RetryAfter)
+
+			continue
+		}
+
+		break
+	}
+
+	if err != nil {
 		result := sources.Result{
 			Type:   sources.ResultError,
 			Source: source.Name(),
@@ -119,142 +327,190 @@ func (source *Source) Enumerate(searchReqURL string, tokens *Tokens, cfg *source
 		return
 	}
 
-	ratelimitRemaining := cast.ToInt64(codeSearchRes.Header.Get(hqgohttpheader.XRatelimitRemaining.String()))
-	if isForbidden && ratelimitRemaining == 0 {
-		retryAfterSeconds := cast.ToInt64(codeSearchRes.Header.Get(hqgohttpheader.RetryAfter.String()))
-
-		tokens.setCurrentTokenExceeded(retryAfterSeconds)
-
-		source.Enumerate(searchReqURL, tokens, cfg, results)
-	}
-
-	var codeSearchResData codeSearchResponse
-
-	if err = json.NewDecoder(codeSearchRes.Body).Decode(&codeSearchResData); err != nil {
+	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
 		result := sources.Result{
 			Type:   sources.ResultError,
 			Source: source.Name(),
-			Error:  err,
+			Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, res, err),
 		}
 
 		results <- result
 
-		codeSearchRes.Body.Close()
+		res.Body.Close()
 
 		return
 	}
 
-	codeSearchRes.Body.Close()
+	res.Body.Close()
 
-	for _, item := range codeSearchResData.Items {
-		getRawContentReqURL := strings.ReplaceAll(item.HTMLURL, "https://github.com/", "https://raw.githubusercontent.com/")
-		getRawContentReqURL = strings.ReplaceAll(getRawContentReqURL, "/blob/", "/")
+	cfg.Statistics.PageFetched()
 
-		var getRawContentRes *http.Response
+	ok = true
 
-		getRawContentRes, err = hqgohttp.Get(getRawContentReqURL)
-		if err != nil {
-			result := sources.Result{
-				Type:   sources.ResultError,
-				Source: source.Name(),
-				Error:  err,
-			}
+	return
+}
 
-			results <- result
+// extract streams the URLs found in a code search item's raw file content and text matches.
+//
+// Binary files (i.e., not served with a textual content type) are skipped, and raw content is
+// read up to the "max_file_size" setting (1 MiB by default).
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the extraction.
+//   - item (codeSearchItem): The code search item.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+func (source *Source) extract(ctx context.Context, item codeSearchItem, cfg *sources.Configuration, results chan sources.Result) {
+	getRawContentReqURL := strings.ReplaceAll(item.HTMLURL, "https://github.com/", "https://raw.githubusercontent.com/")
+	getRawContentReqURL = strings.ReplaceAll(getRawContentReqURL, "/blob/", "/")
 
-			continue
+	getRawContentRes, err := cfg.HTTPClient.Get(ctx, getRawContentReqURL)
+	if err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  err,
 		}
 
-		if getRawContentRes.StatusCode != hqgohttpstatus.OK.Int() {
-			getRawContentRes.Body.Close()
+		results <- result
 
-			continue
-		}
+		return
+	}
 
-		scanner := bufio.NewScanner(getRawContentRes.Body)
+	if isBinary(getRawContentRes.Header.Get(hqgohttpheader.ContentType.String())) {
+		getRawContentRes.Body.Close()
 
-		for scanner.Scan() {
-			line := scanner.Text()
-			if line == "" {
-				continue
-			}
+		return
+	}
 
-			URLs := cfg.Extractor.FindAllString(line, -1)
+	maxFileSize := cfg.Settings[source.Name()].Int("max_file_size", defaultMaxFileSize)
 
-			for _, URL := range URLs {
-				var valid bool
+	scanner := bufio.NewScanner(io.LimitReader(getRawContentRes.Body, int64(maxFileSize)))
 
-				if URL, valid = cfg.Validate(URL); !valid {
-					continue
-				}
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), max(maxFileSize, bufio.MaxScanTokenSize))
 
-				result := sources.Result{
-					Type:   sources.ResultURL,
-					Source: source.Name(),
-					Value:  URL,
-				}
+	for scanner.Scan() {
+		source.emit(scanner.Text(), SurfaceCode, cfg, results)
+	}
 
-				results <- result
-			}
+	if err = scanner.Err(); err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  err,
 		}
 
-		if err = scanner.Err(); err != nil {
-			result := sources.Result{
-				Type:   sources.ResultError,
-				Source: source.Name(),
-				Error:  err,
-			}
+		results <- result
 
-			results <- result
+		getRawContentRes.Body.Close()
 
-			getRawContentRes.Body.Close()
+		return
+	}
+
+	getRawContentRes.Body.Close()
 
+	for _, textMatch := range item.TextMatches {
+		source.emit(textMatch.Fragment, SurfaceCode, cfg, results)
+	}
+}
+
+// emit streams the in scope URLs found in text, tagged with the GitHub surface the text came from.
+//
+// Parameters:
+//   - text (string): The text to extract URLs from.
+//   - surface (string): The GitHub surface the text came from (e.g., SurfaceCode).
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs to.
+func (source *Source) emit(text, surface string, cfg *sources.Configuration, results chan sources.Result) {
+	if text == "" {
+		return
+	}
+
+	for _, URL := range cfg.Extractor.FindAllString(text, -1) {
+		var valid bool
+
+		if URL, valid = cfg.Validate(URL); !valid {
 			continue
 		}
 
-		getRawContentRes.Body.Close()
+		result := sources.Result{
+			Type:   sources.ResultURL,
+			Source: source.Name(),
+			Value:  URL,
+			Metadata: &sources.Metadata{
+				Surface: surface,
+			},
+		}
 
-		for _, textMatch := range item.TextMatches {
-			URLs := cfg.Extractor.FindAllString(textMatch.Fragment, -1)
+		results <- result
+	}
+}
 
-			for _, URL := range URLs {
-				var valid bool
+// searchParams returns the query parameters requesting a page of search results for terms.
+//
+// Parameters:
+//   - terms (string): The search terms.
+//   - page (int): The page to request, starting at 1.
+//
+// Returns:
+//   - params (map[string]string): The query parameters.
+func searchParams(terms string, page int) (params map[string]string) {
+	params = map[string]string{
+		"q":        terms,
+		"per_page": strconv.Itoa(perPage),
+		"page":     strconv.Itoa(page),
+	}
 
-				if URL, valid = cfg.Validate(URL); !valid {
-					continue
-				}
+	return
+}
 
-				result := sources.Result{
-					Type:   sources.ResultURL,
-					Source: source.Name(),
-					Value:  URL,
-				}
+// isBinary reports whether a response with the provided Content-Type header holds binary content.
+// Only text, JSON, XML, JavaScript and YAML content types are considered textual.
+//
+// Parameters:
+//   - contentType (string): The value of the Content-Type header.
+//
+// Returns:
+//   - binary (bool): Whether the content is binary.
+func isBinary(contentType string) (binary bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return
+	}
 
-				results <- result
-			}
+	if strings.HasPrefix(mediaType, "text/") {
+		return
+	}
+
+	for _, textual := range []string{"json", "xml", "javascript", "yaml"} {
+		if strings.Contains(mediaType, textual) {
+			return
 		}
 	}
 
-	links := hqgohttpheader.ParseLinkHeader(codeSearchRes.Header.Get(hqgohttpheader.Link.String()))
+	binary = true
 
-	for _, link := range links {
-		if link.Rel == "next" {
-			nextURL, err := url.QueryUnescape(link.URL)
-			if err != nil {
-				result := sources.Result{
-					Type:   sources.ResultError,
-					Source: source.Name(),
-					Error:  err,
-				}
-
-				results <- result
+	return
+}
 
-				return
-			}
+// pool returns the source's token pool, creating it from keys on first use.
+//
+// Parameters:
+//   - keys ([]string): The GitHub API tokens.
+//
+// Returns:
+//   - tokens (*Tokens): The token pool.
+func (source *Source) pool(keys []string) (tokens *Tokens) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
 
-			source.Enumerate(nextURL, tokens, cfg, results)
-		}
+	if source.tokens == nil {
+		source.tokens = NewTokenManager(keys)
 	}
+
+	tokens = source.tokens
+
+	return
 }
 
 // Name returns the unique identifier for the data source.
@@ -265,3 +521,25 @@ func (source *Source) Enumerate(searchReqURL string, tokens *Tokens, cfg *source
 func (source *Source) Name() (name string) {
 	return sources.GITHUB
 }
+
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.GITHUB, func() sources.Source {
+		return &Source{}
+	}, sources.KeyRequired, 0)
+}
+
+const (
+	// perPage is the number of results requested per page of code search results, the API's maximum.
+	perPage = 100
+	// searchResultsCap is the maximum number of results the code search API returns for a search.
+	searchResultsCap = 1000
+	// maxIndexedFileSize is the size, in bytes, above which GitHub does not index files for code search.
+	maxIndexedFileSize = 384 * 1024
+	// defaultMaxPages is the default maximum number of pages of code search results fetched per scan.
+	defaultMaxPages = 100
+	// defaultConcurrency is the default number of files processed concurrently.
+	defaultConcurrency = 5
+	// defaultMaxFileSize is the default maximum number of bytes of a file's raw content read.
+	defaultMaxFileSize = 1024 * 1024
+)
@@ -0,0 +1,83 @@
+package github
+
+import (
+	"testing"
+)
+
+func TestQueryString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query query
+		want  string
+	}{
+		{query: query{}, want: `"example.com"`},
+		{query: query{minSize: 0, maxSize: 1024}, want: `"example.com" size:0..1024`},
+		{query: query{minSize: 1025, maxSize: 2048}, want: `"example.com" size:1025..2048`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.query.String("example.com"); got != tt.want {
+			t.Errorf("%+v.String() = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQuerySplit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		query     query
+		wantLower query
+		wantUpper query
+		wantOK    bool
+	}{
+		{
+			name:      "unrestricted query splits the indexed size range",
+			query:     query{},
+			wantLower: query{minSize: 0, maxSize: maxIndexedFileSize / 2},
+			wantUpper: query{minSize: maxIndexedFileSize/2 + 1, maxSize: maxIndexedFileSize},
+			wantOK:    true,
+		},
+		{
+			name:      "odd range",
+			query:     query{minSize: 10, maxSize: 15},
+			wantLower: query{minSize: 10, maxSize: 12},
+			wantUpper: query{minSize: 13, maxSize: 15},
+			wantOK:    true,
+		},
+		{
+			name:      "two sizes",
+			query:     query{minSize: 7, maxSize: 8},
+			wantLower: query{minSize: 7, maxSize: 7},
+			wantUpper: query{minSize: 8, maxSize: 8},
+			wantOK:    true,
+		},
+		{
+			name:   "single size",
+			query:  query{minSize: 7, maxSize: 7},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lower, upper, ok := tt.query.split()
+
+			if ok != tt.wantOK {
+				t.Fatalf("split() ok = %v, want %v", ok, tt.wantOK)
+			}
+
+			if !ok {
+				return
+			}
+
+			if lower != tt.wantLower || upper != tt.wantUpper {
+				t.Errorf("split() = %+v, %+v, want %+v, %+v", lower, upper, tt.wantLower, tt.wantUpper)
+			}
+		})
+	}
+}
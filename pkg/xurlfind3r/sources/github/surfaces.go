@@ -0,0 +1,247 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+// issueSearchResponse represents the structure of the JSON response returned by the GitHub issue search API.
+// Pull requests are returned as issues.
+type issueSearchResponse struct {
+	TotalCount int `json:"total_count"`
+	Items      []struct {
+		Title       string `json:"title"`
+		Body        string `json:"body"`
+		TextMatches []struct {
+			Fragment string `json:"fragment"`
+		} `json:"text_matches"`
+	} `json:"items"`
+}
+
+// commitSearchResponse represents the structure of the JSON response returned by the GitHub commit search API.
+type commitSearchResponse struct {
+	TotalCount int `json:"total_count"`
+	Items      []struct {
+		Commit struct {
+			Message string `json:"message"`
+		} `json:"commit"`
+	} `json:"items"`
+}
+
+// getGistResponse represents the structure of the JSON response returned by the GitHub API for a gist.
+// File contents are included up to one megabyte.
+type getGistResponse struct {
+	Description string `json:"description"`
+	Files       map[string]struct {
+		Content string `json:"content"`
+	} `json:"files"`
+}
+
+// enumerateIssues runs GitHub issue searches, which cover pull requests as well, for the domain,
+// and extracts URLs from the titles, bodies and text matches (e.g., in comments) of the issues
+// found. URLs are tagged with the SurfaceIssues surface.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the enumeration.
+//   - domain (string): The target domain.
+//   - tokens (*Tokens): The token pool used to authenticate searches.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+func (source *Source) enumerateIssues(ctx context.Context, domain string, tokens *Tokens, cfg *sources.Configuration, results chan sources.Result) {
+	source.paginate(ctx, cfg, func(page int) (count, total int, ok bool) {
+		var issueSearchResData issueSearchResponse
+
+		if !source.fetch(ctx, "https://api.github.com/search/issues", searchParams(strconv.Quote(domain), page), tokens, cfg, results, &issueSearchResData) {
+			return
+		}
+
+		for _, item := range issueSearchResData.Items {
+			source.emit(item.Title, SurfaceIssues, cfg, results)
+			source.emit(item.Body, SurfaceIssues, cfg, results)
+
+			for _, textMatch := range item.TextMatches {
+				source.emit(textMatch.Fragment, SurfaceIssues, cfg, results)
+			}
+		}
+
+		count, total, ok = len(issueSearchResData.Items), issueSearchResData.TotalCount, true
+
+		return
+	})
+}
+
+// enumerateCommits runs GitHub commit searches for the domain, and extracts URLs from the messages
+// of the commits found. URLs are tagged with the SurfaceCommits surface.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the enumeration.
+//   - domain (string): The target domain.
+//   - tokens (*Tokens): The token pool used to authenticate searches.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+func (source *Source) enumerateCommits(ctx context.Context, domain string, tokens *Tokens, cfg *sources.Configuration, results chan sources.Result) {
+	source.paginate(ctx, cfg, func(page int) (count, total int, ok bool) {
+		var commitSearchResData commitSearchResponse
+
+		if !source.fetch(ctx, "https://api.github.com/search/commits", searchParams(strconv.Quote(domain), page), tokens, cfg, results, &commitSearchResData) {
+			return
+		}
+
+		for _, item := range commitSearchResData.Items {
+			source.emit(item.Commit.Message, SurfaceCommits, cfg, results)
+		}
+
+		count, total, ok = len(commitSearchResData.Items), commitSearchResData.TotalCount, true
+
+		return
+	})
+}
+
+// enumerateGists searches public gists for the domain, and extracts URLs from the descriptions and
+// files of the gists found. URLs are tagged with the SurfaceGists surface.
+//
+// The GitHub API offers no gist search, so gists are found through gist.github.com's search page,
+// ten per page, and then fetched from the API.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the enumeration.
+//   - domain (string): The target domain.
+//   - tokens (*Tokens): The token pool used to authenticate requests for gists.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+func (source *Source) enumerateGists(ctx context.Context, domain string, tokens *Tokens, cfg *sources.Configuration, results chan sources.Result) {
+	seenGists := map[string]struct{}{}
+
+	source.paginate(ctx, cfg, func(page int) (count, total int, ok bool) {
+		searchGistsReqURL := "https://gist.github.com/search"
+		searchGistsReqCFG := &sources.RequestConfiguration{
+			Params: map[string]string{
+				"q": strconv.Quote(domain),
+				"p": strconv.Itoa(page),
+			},
+		}
+
+		searchGistsRes, err := cfg.HTTPClient.Get(ctx, searchGistsReqURL, searchGistsReqCFG)
+		if err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  err,
+			}
+
+			results <- result
+
+			return
+		}
+
+		searchGistsResBody, err := io.ReadAll(searchGistsRes.Body)
+
+		searchGistsRes.Body.Close()
+
+		if err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  err,
+			}
+
+			results <- result
+
+			return
+		}
+
+		cfg.Statistics.PageFetched()
+
+		matches := gistLinkRegex.FindAllStringSubmatch(string(searchGistsResBody), -1)
+
+		for _, match := range matches {
+			ID := match[1]
+
+			if _, seen := seenGists[ID]; seen {
+				continue
+			}
+
+			seenGists[ID] = struct{}{}
+
+			count++
+
+			var getGistResData getGistResponse
+
+			if !source.fetch(ctx, "https://api.github.com/gists/"+url.PathEscape(ID), nil, tokens, cfg, results, &getGistResData) {
+				if ctx.Err() != nil {
+					return
+				}
+
+				continue
+			}
+
+			source.emit(getGistResData.Description, SurfaceGists, cfg, results)
+
+			for _, file := range getGistResData.Files {
+				source.emit(file.Content, SurfaceGists, cfg, results)
+			}
+		}
+
+		// The search page does not report a usable total; keep going while it yields gists.
+		total, ok = searchResultsCap, count > 0
+
+		return
+	})
+}
+
+// paginate walks the pages of a search, starting at 1, until fetching a page fails, a page comes
+// back empty, every result (up to the 1000 GitHub returns) is covered, or the "max_pages" setting
+// is reached.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the search.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - process (func(page int) (count, total int, ok bool)): Fetches and processes a page, returning
+//     the number of results on the page, the total number of results and whether it succeeded.
+func (source *Source) paginate(ctx context.Context, cfg *sources.Configuration, process func(page int) (count, total int, ok bool)) {
+	maxPages := cfg.Settings[source.Name()].Int("max_pages", defaultMaxPages)
+
+	for page := 1; page <= searchResultsCap/perPage; page++ {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if maxPages > 0 && page > maxPages {
+			return
+		}
+
+		count, total, ok := process(page)
+		if !ok || count == 0 || page*perPage >= total {
+			return
+		}
+	}
+}
+
+// Constants naming the GitHub surfaces searched by the source, as used in the "surfaces" setting
+// and in the Surface of the metadata of the URLs found.
+//
+// List of Constants:
+//   - SurfaceCode: Code, searched through the code search API.
+//   - SurfaceIssues: Issues and pull requests, including their comments.
+//   - SurfaceCommits: Commit messages.
+//   - SurfaceGists: Public gists.
+const (
+	SurfaceCode    = "code"
+	SurfaceIssues  = "issues"
+	SurfaceCommits = "commits"
+	SurfaceGists   = "gists"
+)
+
+var (
+	// gistLinkRegex matches links to gists on gist.github.com's search page, capturing their IDs.
+	gistLinkRegex = regexp.MustCompile(`href="(?:https://gist\.github\.com)?/[\w-]+/([0-9a-f]{20,32})"`)
+
+	// errUnknownSurface is returned for surfaces in the "surfaces" setting the source does not know.
+	errUnknownSurface = errors.New("unknown GitHub surface")
+)
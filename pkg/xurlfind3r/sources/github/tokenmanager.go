@@ -1,63 +1,247 @@
 package github
 
-import "time"
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
 
+	hqgohttpheader "github.com/hueristiq/hq-go-http/header"
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+	"github.com/spf13/cast"
+)
+
+// Token tracks the rate limit budget of a single GitHub API token.
+//
+// Fields:
+//   - Hash (string): The token itself.
+//   - Remaining (int): The number of requests left in the current rate limit window, or -1 if unknown.
+//   - Reset (time.Time): The time at which the current rate limit window resets.
+//   - BlockedUntil (time.Time): The time until which the token must not be used, set on
+//     secondary rate limits.
 type Token struct {
 	Hash         string
-	RetryAfter   int64
-	ExceededTime time.Time
+	Remaining    int
+	Reset        time.Time
+	BlockedUntil time.Time
+}
+
+// available reports whether the token may be used at now, and if not, when it may be used again.
+func (token *Token) available(now time.Time) (ok bool, at time.Time) {
+	at = token.BlockedUntil
+
+	if token.Remaining == 0 && token.Reset.After(at) {
+		at = token.Reset
+	}
+
+	ok = !at.After(now)
+
+	return
 }
 
+// budget returns the number of requests the token is known to have left, unknown budgets ranking first.
+func (token *Token) budget() int {
+	if token.Remaining < 0 {
+		return math.MaxInt
+	}
+
+	return token.Remaining
+}
+
+// Tokens is a pool of GitHub API tokens, safe for concurrent use.
+//
+// It tracks every token's budget from the X-RateLimit-Remaining and X-RateLimit-Reset headers of
+// the responses it is given, sets tokens aside on secondary rate limits, and hands out the token
+// with the most remaining budget. A single pool may be shared by concurrent scans.
+//
+// Fields:
+//   - mutex (sync.Mutex): Guards pool.
+//   - pool ([]*Token): The tokens.
 type Tokens struct {
-	current int
-	pool    []Token
+	mutex sync.Mutex
+	pool  []*Token
 }
 
-func NewTokenManager(keys []string) *Tokens {
-	pool := []Token{}
+// Acquire returns the available token with the most remaining budget, waiting for one to become
+// available if every token is exhausted or blocked.
+//
+// Parameters:
+//   - ctx (context.Context): The context bounding the wait.
+//
+// Returns:
+//   - hash (string): The acquired token.
+//   - err (error): sources.ErrNoKeys if the pool is empty, or the context's error if it is done
+//     before a token becomes available.
+func (tokens *Tokens) Acquire(ctx context.Context) (hash string, err error) {
+	if len(tokens.pool) == 0 {
+		err = sources.ErrNoKeys
 
-	for _, key := range keys {
-		t := Token{Hash: key, ExceededTime: time.Time{}, RetryAfter: 0}
-		pool = append(pool, t)
+		return
 	}
 
-	return &Tokens{
-		current: 0,
-		pool:    pool,
+	for {
+		var (
+			ok   bool
+			wait time.Duration
+		)
+
+		hash, ok, wait = tokens.pick()
+		if ok {
+			return
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			err = ctx.Err()
+
+			return
+		case <-timer.C:
+		}
 	}
 }
 
-func (r *Tokens) setCurrentTokenExceeded(retryAfter int64) {
-	if r.current >= len(r.pool) {
-		r.current %= len(r.pool)
+// pick selects the available token with the most remaining budget, reserving one request of it.
+// If no token is available, it returns how long to wait until one is.
+func (tokens *Tokens) pick() (hash string, ok bool, wait time.Duration) {
+	tokens.mutex.Lock()
+	defer tokens.mutex.Unlock()
+
+	now := time.Now()
+
+	var (
+		best     *Token
+		earliest time.Time
+	)
+
+	for _, token := range tokens.pool {
+		available, at := token.available(now)
+		if !available {
+			if earliest.IsZero() || at.Before(earliest) {
+				earliest = at
+			}
+
+			continue
+		}
+
+		// A past reset means a new window has started.
+		if token.Remaining == 0 {
+			token.Remaining = -1
+		}
+
+		if best == nil || token.budget() > best.budget() {
+			best = token
+		}
+	}
+
+	if best == nil {
+		wait = time.Until(earliest)
+
+		return
 	}
 
-	if r.pool[r.current].RetryAfter == 0 {
-		r.pool[r.current].ExceededTime = time.Now()
-		r.pool[r.current].RetryAfter = retryAfter
+	if best.Remaining > 0 {
+		best.Remaining--
 	}
+
+	hash, ok = best.Hash, true
+
+	return
 }
 
-func (r *Tokens) Get() *Token {
-	resetExceededTokens(r)
+// Update records the rate limit budget reported by a response obtained with the token.
+// Responses without rate limit headers, and a nil response, are ignored.
+//
+// Parameters:
+//   - hash (string): The token the request was made with.
+//   - res (*http.Response): The response to the request, or nil.
+func (tokens *Tokens) Update(hash string, res *http.Response) {
+	if res == nil {
+		return
+	}
+
+	value := res.Header.Get(hqgohttpheader.XRatelimitRemaining.String())
+	if value == "" {
+		return
+	}
 
-	if r.current >= len(r.pool) {
-		r.current %= len(r.pool)
+	remaining, err := cast.ToIntE(value)
+	if err != nil {
+		return
 	}
 
-	result := &r.pool[r.current]
-	r.current++
+	reset := cast.ToInt64(res.Header.Get(xRatelimitResetHeader))
 
-	return result
+	tokens.mutex.Lock()
+	defer tokens.mutex.Unlock()
+
+	for _, token := range tokens.pool {
+		if token.Hash != hash {
+			continue
+		}
+
+		token.Remaining = remaining
+
+		if reset > 0 {
+			token.Reset = time.Unix(reset, 0)
+		}
+	}
 }
 
-func resetExceededTokens(r *Tokens) {
-	for i, token := range r.pool {
-		if token.RetryAfter > 0 {
-			if int64(time.Since(token.ExceededTime)/time.Second) > token.RetryAfter {
-				r.pool[i].ExceededTime = time.Time{}
-				r.pool[i].RetryAfter = 0
-			}
+// Exceeded sets the token aside after it hit a rate limit.
+//
+// Parameters:
+//   - hash (string): The token that hit the rate limit.
+//   - retryAfter (time.Duration): How long GitHub asked to wait. When zero, the token waits for its
+//     rate limit window to reset if it is exhausted, or for a minute otherwise, as GitHub recommends
+//     for secondary rate limits.
+func (tokens *Tokens) Exceeded(hash string, retryAfter time.Duration) {
+	tokens.mutex.Lock()
+	defer tokens.mutex.Unlock()
+
+	now := time.Now()
+
+	for _, token := range tokens.pool {
+		if token.Hash != hash {
+			continue
 		}
+
+		switch {
+		case retryAfter > 0:
+			token.BlockedUntil = now.Add(retryAfter)
+		case token.Remaining == 0 && token.Reset.After(now):
+			token.BlockedUntil = token.Reset
+		default:
+			token.BlockedUntil = now.Add(time.Minute)
+		}
+	}
+}
+
+// NewTokenManager creates a pool of the provided GitHub API tokens, all with an unknown budget.
+//
+// Parameters:
+//   - keys ([]string): The tokens.
+//
+// Returns:
+//   - tokens (*Tokens): The new pool.
+func NewTokenManager(keys []string) (tokens *Tokens) {
+	tokens = &Tokens{
+		pool: make([]*Token, 0, len(keys)),
 	}
+
+	for _, key := range keys {
+		tokens.pool = append(tokens.pool, &Token{
+			Hash:      key,
+			Remaining: -1,
+		})
+	}
+
+	return
 }
+
+// xRatelimitResetHeader is the header carrying the Unix time at which the rate limit window resets.
+const xRatelimitResetHeader = "X-Ratelimit-Reset"
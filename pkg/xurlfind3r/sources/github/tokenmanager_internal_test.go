@@ -0,0 +1,153 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+func TestTokensPick(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		pool     []*Token
+		wantHash string
+		wantOK   bool
+		wantWait time.Duration
+	}{
+		{
+			name: "unknown budget ranks first",
+			pool: []*Token{
+				{Hash: "a", Remaining: 10},
+				{Hash: "b", Remaining: -1},
+			},
+			wantHash: "b",
+			wantOK:   true,
+		},
+		{
+			name: "most remaining budget",
+			pool: []*Token{
+				{Hash: "a", Remaining: 10},
+				{Hash: "b", Remaining: 20},
+				{Hash: "c", Remaining: 5},
+			},
+			wantHash: "b",
+			wantOK:   true,
+		},
+		{
+			name: "blocked token is skipped",
+			pool: []*Token{
+				{Hash: "a", Remaining: 20, BlockedUntil: now.Add(time.Minute)},
+				{Hash: "b", Remaining: 5},
+			},
+			wantHash: "b",
+			wantOK:   true,
+		},
+		{
+			name: "exhausted token whose window reset is usable again",
+			pool: []*Token{
+				{Hash: "a", Remaining: 5},
+				{Hash: "b", Remaining: 0, Reset: now.Add(-time.Second)},
+			},
+			wantHash: "b",
+			wantOK:   true,
+		},
+		{
+			name: "no token available waits for the earliest",
+			pool: []*Token{
+				{Hash: "a", Remaining: 0, Reset: now.Add(time.Hour)},
+				{Hash: "b", Remaining: 5, BlockedUntil: now.Add(time.Minute)},
+			},
+			wantOK:   false,
+			wantWait: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tokens := &Tokens{pool: tt.pool}
+
+			hash, ok, wait := tokens.pick()
+
+			if ok != tt.wantOK {
+				t.Fatalf("pick() ok = %v, want %v", ok, tt.wantOK)
+			}
+
+			if hash != tt.wantHash {
+				t.Errorf("pick() hash = %q, want %q", hash, tt.wantHash)
+			}
+
+			if !ok && (wait <= tt.wantWait-time.Second || wait > tt.wantWait) {
+				t.Errorf("pick() wait = %v, want about %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestTokensPickReservesBudget(t *testing.T) {
+	t.Parallel()
+
+	reset := time.Now().Add(time.Hour)
+
+	tokens := &Tokens{pool: []*Token{{Hash: "a", Remaining: 2, Reset: reset}, {Hash: "b", Remaining: 2, Reset: reset}}}
+
+	var picked []string
+
+	for range 4 {
+		hash, ok, _ := tokens.pick()
+		if !ok {
+			t.Fatalf("pick() ok = false, want true")
+		}
+
+		picked = append(picked, hash)
+	}
+
+	if _, ok, _ := tokens.pick(); ok {
+		t.Errorf("pick() with every budget spent ok = true, want false")
+	}
+
+	if picked[0] == picked[1] {
+		t.Errorf("pick() = %v, want tokens to alternate as their budgets shrink", picked)
+	}
+}
+
+func TestTokensUpdate(t *testing.T) {
+	t.Parallel()
+
+	tokens := NewTokenManager([]string{"a", "b"})
+
+	reset := time.Now().Add(time.Hour).Unix()
+
+	res := &http.Response{Header: http.Header{}}
+
+	res.Header.Set("X-RateLimit-Remaining", "0")
+	res.Header.Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+
+	tokens.Update("a", res)
+	tokens.Update("b", nil)
+
+	if hash, ok, _ := tokens.pick(); !ok || hash != "b" {
+		t.Errorf("pick() = %q, %v, want \"b\", true", hash, ok)
+	}
+
+	if token := tokens.pool[0]; token.Remaining != 0 || token.Reset.Unix() != reset {
+		t.Errorf("Update() token = %+v, want remaining 0 and reset %d", token, reset)
+	}
+}
+
+func TestTokensAcquireEmpty(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewTokenManager(nil).Acquire(t.Context()); !errors.Is(err, sources.ErrNoKeys) {
+		t.Errorf("Acquire() error = %v, want %v", err, sources.ErrNoKeys)
+	}
+}
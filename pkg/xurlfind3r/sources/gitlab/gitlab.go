@@ -0,0 +1,277 @@
+// Package gitlab provides an implementation of the sources.Source interface
+// for interacting with the GitLab Search API.
+//
+// The GitLab API allows searching the code (blobs) of the projects hosted on an instance for
+// occurrences of a given domain, which can reveal URLs or references associated with that domain.
+// This package defines a Source type that implements the Run and Name methods as specified by the
+// sources.Source interface. The Run method pages through the blob search results of gitlab.com, or
+// of the self-hosted instance set in the "url" setting, extracts URLs from both the raw content and
+// the matched fragments of every blob found, and streams discovered URLs or errors via a channel.
+package gitlab
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+// searchBlobsResponse represents the structure of the JSON response returned by the GitLab
+// search API for the blobs scope: a page of blobs matching the search.
+type searchBlobsResponse []searchBlobsItem
+
+// searchBlobsItem represents a single blob search result. It includes the project the blob belongs
+// to, the path of the file, the ref it was found at and the matched fragment of the file.
+type searchBlobsItem struct {
+	ProjectID int    `json:"project_id"`
+	Path      string `json:"path"`
+	Ref       string `json:"ref"`
+	Data      string `json:"data"`
+}
+
+// Source represents the GitLab data source implementation.
+// It implements the sources.Source interface, providing functionality
+// for retrieving URLs by querying GitLab blob search results.
+type Source struct{}
+
+// Run initiates the process of retrieving URL information from GitLab for a given domain.
+//
+// Searches are run against the instance set in the "url" setting (https://gitlab.com by default),
+// authenticated with a randomly picked token. Pages are followed through the X-Next-Page header,
+// up to the "max_pages" setting (100 by default, zero meaning no limit), and every file found is
+// processed once.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
+//   - domain (string): The target domain for which URLs are to be retrieved.
+//   - cfg (*sources.Configuration): The configuration instance containing API keys,
+//     the URL validation function, and any additional settings required by the source.
+//
+// Returns:
+//   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
+//     Each result is either a discovered URL (ResultURL) or an error (ResultError)
+//     encountered during the operation.
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
+	results := make(chan sources.Result)
+
+	go func() {
+		defer close(results)
+
+		key, err := cfg.Keys[source.Name()].PickRandom()
+		if err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMissingKey, nil, err),
+			}
+
+			results <- result
+
+			return
+		}
+
+		instanceURL := strings.TrimSuffix(cfg.Settings[source.Name()].String("url", defaultInstanceURL), "/")
+
+		maxPages := cfg.Settings[source.Name()].Int("max_pages", defaultMaxPages)
+
+		seenFiles := map[string]struct{}{}
+
+		page := "1"
+
+		for fetched := 0; page != ""; fetched++ {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if maxPages > 0 && fetched >= maxPages {
+				return
+			}
+
+			searchBlobsReqURL := instanceURL + "/api/v4/search"
+			searchBlobsReqCFG := &sources.RequestConfiguration{
+				Params: map[string]string{
+					"scope":    "blobs",
+					"search":   strconv.Quote(domain),
+					"per_page": strconv.Itoa(perPage),
+					"page":     page,
+				},
+				Headers: map[string]string{
+					privateTokenHeader: key,
+				},
+			}
+
+			searchBlobsRes, err := cfg.HTTPClient.Get(ctx, searchBlobsReqURL, searchBlobsReqCFG)
+			if err != nil {
+				result := sources.Result{
+					Type:   sources.ResultError,
+					Source: source.Name(),
+					Error:  err,
+				}
+
+				results <- result
+
+				return
+			}
+
+			var searchBlobsResData searchBlobsResponse
+
+			if err = json.NewDecoder(searchBlobsRes.Body).Decode(&searchBlobsResData); err != nil {
+				result := sources.Result{
+					Type:   sources.ResultError,
+					Source: source.Name(),
+					Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, searchBlobsRes, err),
+				}
+
+				results <- result
+
+				searchBlobsRes.Body.Close()
+
+				return
+			}
+
+			searchBlobsRes.Body.Close()
+
+			cfg.Statistics.PageFetched()
+
+			page = searchBlobsRes.Header.Get(xNextPageHeader)
+
+			for _, item := range searchBlobsResData {
+				file := fmt.Sprintf("%d:%s:%s", item.ProjectID, item.Ref, item.Path)
+
+				if _, seen := seenFiles[file]; seen {
+					continue
+				}
+
+				seenFiles[file] = struct{}{}
+
+				source.extract(ctx, instanceURL, key, item, cfg, results)
+			}
+		}
+	}()
+
+	return results
+}
+
+// extract streams the URLs found in a blob search item's raw file content and matched fragment.
+// Raw content is read up to the "max_file_size" setting (1 MiB by default).
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the extraction.
+//   - instanceURL (string): The base URL of the GitLab instance.
+//   - key (string): The token used to authenticate the request.
+//   - item (searchBlobsItem): The blob search item.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+func (source *Source) extract(ctx context.Context, instanceURL, key string, item searchBlobsItem, cfg *sources.Configuration, results chan sources.Result) {
+	getRawFileReqURL := fmt.Sprintf("%s/api/v4/projects/%d/repository/files/%s/raw", instanceURL, item.ProjectID, url.PathEscape(item.Path))
+	getRawFileReqCFG := &sources.RequestConfiguration{
+		Params: map[string]string{
+			"ref": item.Ref,
+		},
+		Headers: map[string]string{
+			privateTokenHeader: key,
+		},
+	}
+
+	getRawFileRes, err := cfg.HTTPClient.Get(ctx, getRawFileReqURL, getRawFileReqCFG)
+	if err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  err,
+		}
+
+		results <- result
+
+		return
+	}
+
+	maxFileSize := cfg.Settings[source.Name()].Int("max_file_size", defaultMaxFileSize)
+
+	scanner := bufio.NewScanner(io.LimitReader(getRawFileRes.Body, int64(maxFileSize)))
+
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), max(maxFileSize, bufio.MaxScanTokenSize))
+
+	for scanner.Scan() {
+		source.emit(scanner.Text(), cfg, results)
+	}
+
+	if err = scanner.Err(); err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  err,
+		}
+
+		results <- result
+
+		getRawFileRes.Body.Close()
+
+		return
+	}
+
+	getRawFileRes.Body.Close()
+
+	source.emit(item.Data, cfg, results)
+}
+
+// emit streams the in scope URLs found in text.
+//
+// Parameters:
+//   - text (string): The text to extract URLs from.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs to.
+func (source *Source) emit(text string, cfg *sources.Configuration, results chan sources.Result) {
+	for _, URL := range cfg.Extractor.FindAllString(text, -1) {
+		var valid bool
+
+		if URL, valid = cfg.Validate(URL); !valid {
+			continue
+		}
+
+		result := sources.Result{
+			Type:   sources.ResultURL,
+			Source: source.Name(),
+			Value:  URL,
+		}
+
+		results <- result
+	}
+}
+
+// Name returns the unique identifier for the data source.
+// This identifier is used for logging, debugging, and associating results with the correct data source.
+//
+// Returns:
+//   - name (string): The unique identifier for the data source.
+func (source *Source) Name() (name string) {
+	return sources.GITLAB
+}
+
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.GITLAB, func() sources.Source {
+		return &Source{}
+	}, sources.KeyRequired, 0)
+}
+
+const (
+	// defaultInstanceURL is the base URL of the GitLab instance searched by default.
+	defaultInstanceURL = "https://gitlab.com"
+	// perPage is the number of results requested per page of blob search results, the API's maximum.
+	perPage = 100
+	// defaultMaxPages is the default maximum number of pages of blob search results fetched per scan.
+	defaultMaxPages = 100
+	// defaultMaxFileSize is the default maximum number of bytes of a file's raw content read.
+	defaultMaxFileSize = 1024 * 1024
+	// privateTokenHeader is the header carrying the token requests are authenticated with.
+	privateTokenHeader = "PRIVATE-TOKEN"
+	// xNextPageHeader is the header carrying the number of the next page of results, empty on the last page.
+	xNextPageHeader = "X-Next-Page"
+)
@@ -0,0 +1,155 @@
+package gitlab
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/internal/sourcestest"
+)
+
+func TestSourceRun(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v4/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(privateTokenHeader) != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		switch page {
+		case 1:
+			w.Header().Set(xNextPageHeader, "2")
+
+			w.Write([]byte(`[{"project_id":1,"path":"a.txt","ref":"main","data":"https://example.com/fragment"}]`))
+		case 2:
+			// The same file again, which is processed once.
+			w.Write([]byte(`[{"project_id":1,"path":"a.txt","ref":"main","data":"https://example.com/fragment"},{"project_id":2,"path":"dir/b.txt","ref":"dev","data":""}]`))
+		default:
+			t.Errorf("unexpected page %d", page)
+		}
+	})
+
+	mux.HandleFunc("GET /api/v4/projects/{id}/repository/files/{path}/raw", func(w http.ResponseWriter, r *http.Request) {
+		file := r.PathValue("id") + ":" + r.URL.Query().Get("ref") + ":" + r.PathValue("path")
+
+		switch file {
+		case "1:main:a.txt":
+			w.Write([]byte("see https://example.com/a\nand https://other.com/x\n"))
+		case "2:dev:dir/b.txt":
+			w.Write([]byte("https://sub.example.com/b"))
+		default:
+			t.Errorf("unexpected file %s", file)
+		}
+	})
+
+	cfg := sourcestest.Configuration(t, "example.com", mux)
+
+	cfg.Keys[sources.GITLAB] = []string{"token"}
+
+	source := &Source{}
+
+	URLs, errs := sourcestest.Run(t.Context(), source, "example.com", cfg)
+
+	if len(errs) > 0 {
+		t.Errorf("Run() errors = %v, want none", errs)
+	}
+
+	want := []string{"https://example.com/a", "https://example.com/fragment", "https://sub.example.com/b"}
+
+	if !slices.Equal(URLs, want) {
+		t.Errorf("Run() URLs = %v, want %v", URLs, want)
+	}
+
+	if got := cfg.Statistics.Pages.Load(); got != 2 {
+		t.Errorf("Statistics.Pages = %d, want 2", got)
+	}
+}
+
+func TestSourceRunMaxPages(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int64
+
+	cfg := sourcestest.Configuration(t, "example.com", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		page := requests.Add(1)
+
+		w.Header().Set(xNextPageHeader, strconv.FormatInt(page+1, 10))
+
+		w.Write([]byte(`[]`))
+	}))
+
+	cfg.Keys[sources.GITLAB] = []string{"token"}
+	cfg.Settings[sources.GITLAB] = sources.Settings{"max_pages": 3}
+
+	if _, errs := sourcestest.Run(t.Context(), &Source{}, "example.com", cfg); len(errs) > 0 {
+		t.Errorf("Run() errors = %v, want none", errs)
+	}
+
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Run() made %d requests, want 3", got)
+	}
+}
+
+func TestSourceRunErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		keys    []string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{
+			name:    "no key",
+			wantErr: sources.ErrMissingKey,
+		},
+		{
+			name:    "rejected key",
+			keys:    []string{"revoked"},
+			status:  http.StatusUnauthorized,
+			wantErr: sources.ErrAuthFailed,
+		},
+		{
+			name:    "malformed page",
+			keys:    []string{"token"},
+			status:  http.StatusOK,
+			body:    `{`,
+			wantErr: sources.ErrMalformedResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := sourcestest.Configuration(t, "example.com", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+
+				w.Write([]byte(tt.body))
+			}))
+
+			cfg.Keys[sources.GITLAB] = tt.keys
+
+			URLs, errs := sourcestest.Run(t.Context(), &Source{}, "example.com", cfg)
+
+			if len(URLs) > 0 {
+				t.Errorf("Run() URLs = %v, want none", URLs)
+			}
+
+			if len(errs) != 1 || !errors.Is(errs[0], tt.wantErr) {
+				t.Errorf("Run() errors = %v, want a single error wrapping %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
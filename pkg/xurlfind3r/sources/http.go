@@ -0,0 +1,364 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	hqgohttp "github.com/hueristiq/hq-go-http"
+	hqgohttpmethod "github.com/hueristiq/hq-go-http/method"
+	hqgohttprequest "github.com/hueristiq/hq-go-http/request"
+)
+
+// HTTPClient performs the HTTP requests issued by sources.
+//
+// It wraps an hq-go-http client and binds every request to a context, so that cancelling
+// a scan (or reaching its deadline) aborts in-flight requests as well as pending retries.
+// Responses are validated before they are handed to sources: rate limited and transient
+// server failures are retried with backoff (honouring Retry-After), and any other
+// unsuccessful response is converted into an Error.
+//
+// Fields:
+//   - client (*hqgohttp.Client): The underlying hq-go-http client used to execute requests.
+//   - source (string): The name of the source errors are attributed to.
+//   - headers (map[string]string): Headers set on every request (e.g., User-Agent).
+//   - retry (hqgohttp.RequestConfiguration): Retry policy, backoff and response drain settings
+//     applied to every request.
+//   - statistics (*Statistics): The statistics requests are counted in, or nil.
+//   - limiter (*Limiter): The limiter pacing requests, or nil.
+type HTTPClient struct {
+	client     *hqgohttp.Client
+	source     string
+	headers    map[string]string
+	retry      hqgohttp.RequestConfiguration
+	statistics *Statistics
+	limiter    *Limiter
+}
+
+// WithSource returns a copy of the client that attributes the errors it reports to the named source.
+// The copy shares the underlying connections with the original client.
+//
+// Parameters:
+//   - name (string): The name of the source.
+//
+// Returns:
+//   - attributing (*HTTPClient): The attributing copy of the client.
+func (client *HTTPClient) WithSource(name string) (attributing *HTTPClient) {
+	copied := *client
+
+	copied.source = name
+
+	return &copied
+}
+
+// WithStatistics returns a copy of the client that counts the requests it makes in statistics.
+// The copy shares the underlying connections with the original client.
+//
+// Parameters:
+//   - statistics (*Statistics): The statistics to count requests in.
+//
+// Returns:
+//   - counting (*HTTPClient): The counting copy of the client.
+func (client *HTTPClient) WithStatistics(statistics *Statistics) (counting *HTTPClient) {
+	copied := *client
+
+	copied.statistics = statistics
+
+	return &copied
+}
+
+// WithLimiter returns a copy of the client that paces the requests it makes, retries included,
+// with limiter. The copy shares the underlying connections with the original client.
+//
+// Parameters:
+//   - limiter (*Limiter): The limiter to pace requests with, or nil for no limit.
+//
+// Returns:
+//   - limited (*HTTPClient): The limited copy of the client.
+func (client *HTTPClient) WithLimiter(limiter *Limiter) (limited *HTTPClient) {
+	copied := *client
+
+	copied.limiter = limiter
+
+	return &copied
+}
+
+// Get performs a context-aware HTTP GET request.
+//
+// Parameters:
+//   - ctx (context.Context): The context the request is bound to.
+//   - URL (string): The target URL for the GET request.
+//   - configurations (...*RequestConfiguration): Optional request configuration overrides.
+//
+// Returns:
+//   - res (*http.Response): The response received from the server.
+//   - err (error): An error if the request fails.
+func (client *HTTPClient) Get(ctx context.Context, URL string, configurations ...*RequestConfiguration) (res *http.Response, err error) {
+	return client.Request(ctx, hqgohttpmethod.GET, URL, nil, configurations...)
+}
+
+// Post performs a context-aware HTTP POST request with the provided body.
+//
+// Parameters:
+//   - ctx (context.Context): The context the request is bound to.
+//   - URL (string): The target URL for the POST request.
+//   - body (interface{}): The payload to include in the POST request.
+//   - configurations (...*RequestConfiguration): Optional request configuration overrides.
+//
+// Returns:
+//   - res (*http.Response): The response received from the server.
+//   - err (error): An error if the request fails.
+func (client *HTTPClient) Post(ctx context.Context, URL string, body interface{}, configurations ...*RequestConfiguration) (res *http.Response, err error) {
+	return client.Request(ctx, hqgohttpmethod.POST, URL, body, configurations...)
+}
+
+// Request builds and executes a context-aware HTTP request.
+//
+// Query parameters from the configurations are appended to the URL, and headers are applied
+// on top of the client's default headers, later configurations overriding earlier ones.
+//
+// Rate limited (429) and transient server failures (500, 502, 503 and 504) are retried up to
+// the retry policy's maximum, waiting for as long as the Retry-After header asks or backing off
+// exponentially otherwise. Waits longer than the policy's maximum wait are not attempted. Once
+// retries are exhausted, or for any other unsuccessful status, an *Error classifying the status
+// is returned along with the response, whose body is already closed, so that callers may still
+// inspect its headers.
+//
+// Parameters:
+//   - ctx (context.Context): The context the request is bound to.
+//   - method (hqgohttpmethod.Method): The HTTP method to use.
+//   - URL (string): The target URL.
+//   - body (interface{}): The request payload, or nil.
+//   - configurations (...*RequestConfiguration): Optional request configuration overrides.
+//
+// Returns:
+//   - res (*http.Response): The response received from the server.
+//   - err (error): An error if the request fails, the response is unsuccessful or the context is done.
+func (client *HTTPClient) Request(ctx context.Context, method hqgohttpmethod.Method, URL string, body interface{}, configurations ...*RequestConfiguration) (res *http.Response, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	var parsed *url.URL
+
+	parsed, err = url.Parse(URL)
+	if err != nil {
+		return
+	}
+
+	query := parsed.Query()
+
+	for _, configuration := range configurations {
+		for k, v := range configuration.Params {
+			query.Set(k, v)
+		}
+	}
+
+	parsed.RawQuery = query.Encode()
+
+	var req *hqgohttprequest.Request
+
+	req, err = hqgohttprequest.NewWithContext(ctx, method.String(), parsed.String(), body)
+	if err != nil {
+		return
+	}
+
+	for k, v := range client.headers {
+		req.Header.Set(k, v)
+	}
+
+	for _, configuration := range configurations {
+		for k, v := range configuration.Headers {
+			req.Header.Set(k, v)
+		}
+	}
+
+	retry := client.retry
+
+	handleRateLimits := false
+
+	for _, configuration := range configurations {
+		handleRateLimits = handleRateLimits || configuration.HandleRateLimits
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err = client.limiter.Wait(ctx); err != nil {
+			return
+		}
+
+		if client.statistics != nil {
+			client.statistics.Requests.Add(1)
+		}
+
+		res, err = client.client.Do(req, &retry)
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+
+				return
+			}
+
+			transportErr := NewError(client.source, ErrorKindTransport, nil, unwrapTransportError(err))
+
+			transportErr.URL = RedactURL(parsed.String())
+
+			err = transportErr
+
+			return
+		}
+
+		if isSuccessStatus(res.StatusCode) {
+			return
+		}
+
+		statusErr := newStatusError(client.source, res)
+
+		discardBody(res, retry.RespReadLimit)
+
+		wait, retryable := client.backoff(statusErr, attempt)
+		if !retryable || (handleRateLimits && statusErr.Kind == ErrorKindRateLimited) {
+			err = statusErr
+
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// backoff decides whether a request that failed with err should be retried, and after how long.
+//
+// Parameters:
+//   - err (*Error): The error the request failed with.
+//   - attempt (int): The zero-based number of the attempt that failed.
+//
+// Returns:
+//   - wait (time.Duration): The duration to wait before retrying.
+//   - retryable (bool): Whether the request should be retried.
+func (client *HTTPClient) backoff(err *Error, attempt int) (wait time.Duration, retryable bool) {
+	if attempt >= client.retry.RetryMax {
+		return
+	}
+
+	if !isTransientStatus(err.StatusCode) && (err.Kind != ErrorKindRateLimited || err.RetryAfter == 0) {
+		return
+	}
+
+	wait = err.RetryAfter
+
+	if wait == 0 {
+		wait = min(client.retry.RetryWaitMin<<attempt, client.retry.RetryWaitMax)
+	}
+
+	retryable = wait <= client.retry.RetryWaitMax
+
+	return
+}
+
+// unwrapTransportError strips the request URL, which may carry keys, from a transport error
+// returned by hq-go-http, returning the underlying cause (e.g., a failed dial).
+//
+// Parameters:
+//   - err (error): The transport error.
+//
+// Returns:
+//   - cause (error): The underlying cause of err.
+func unwrapTransportError(err error) (cause error) {
+	var URLErr *url.Error
+
+	if errors.As(err, &URLErr) {
+		cause = URLErr.Err
+
+		return
+	}
+
+	cause = errors.Unwrap(err)
+
+	if cause == nil {
+		cause = err
+	}
+
+	return
+}
+
+// HTTPClientConfiguration holds the settings used to create an HTTPClient.
+//
+// Fields:
+//   - Timeout (time.Duration): The maximum duration allowed for each HTTP request.
+//   - Headers (map[string]string): Headers set on every request (e.g., User-Agent).
+//   - Transport (http.RoundTripper): The transport requests are sent with, or nil for hq-go-http's
+//     default (e.g., to route requests to a test server).
+type HTTPClientConfiguration struct {
+	Timeout   time.Duration
+	Headers   map[string]string
+	Transport http.RoundTripper
+}
+
+// RequestConfiguration holds request-specific settings for a source's HTTP request.
+//
+// Fields:
+//   - Params (map[string]string): Query parameters appended to the request URL.
+//   - Headers (map[string]string): Headers set on the request.
+//   - HandleRateLimits (bool): Whether to return rate limited responses right away instead of retrying
+//     them, for sources that handle rate limits themselves (e.g., by switching keys).
+type RequestConfiguration struct {
+	Params           map[string]string
+	Headers          map[string]string
+	HandleRateLimits bool
+}
+
+// NewHTTPClient creates an HTTPClient based on hq-go-http's spraying client defaults.
+//
+// Parameters:
+//   - cfg (*HTTPClientConfiguration): The settings for the client.
+//
+// Returns:
+//   - client (*HTTPClient): A pointer to the initialized HTTPClient.
+//   - err (error): An error if the underlying hq-go-http client could not be created.
+func NewHTTPClient(cfg *HTTPClientConfiguration) (client *HTTPClient, err error) {
+	cc := *hqgohttp.DefaultSprayingClientConfiguration
+
+	cc.Headers = []hqgohttp.Header{}
+
+	if cfg.Timeout > 0 {
+		cc.Timeout = cfg.Timeout
+	}
+
+	if cfg.Transport != nil {
+		cc.Client = &http.Client{
+			Transport: cfg.Transport,
+		}
+	}
+
+	client = &HTTPClient{
+		headers: map[string]string{},
+	}
+
+	for k, v := range cfg.Headers {
+		client.headers[k] = v
+	}
+
+	client.client, err = hqgohttp.NewClient(&cc)
+	if err != nil {
+		return
+	}
+
+	client.retry = hqgohttp.RequestConfiguration{
+		RespReadLimit: cc.RespReadLimit,
+		RetryPolicy:   cc.RetryPolicy,
+		RetryMax:      cc.RetryMax,
+		RetryWaitMin:  cc.RetryWaitMin,
+		RetryWaitMax:  cc.RetryWaitMax,
+		RetryBackoff:  cc.RetryBackoff,
+	}
+
+	return
+}
@@ -0,0 +1,143 @@
+package sources
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	hqgohttp "github.com/hueristiq/hq-go-http"
+)
+
+func TestHTTPClientBackoff(t *testing.T) {
+	t.Parallel()
+
+	client := &HTTPClient{
+		retry: hqgohttp.RequestConfiguration{
+			RetryMax:     10,
+			RetryWaitMin: time.Second,
+			RetryWaitMax: 30 * time.Second,
+		},
+	}
+
+	tests := []struct {
+		name          string
+		err           *Error
+		attempt       int
+		wantWait      time.Duration
+		wantRetryable bool
+	}{
+		{
+			name:          "transient status backs off exponentially",
+			err:           &Error{Kind: ErrorKindUpstream, StatusCode: 503},
+			attempt:       2,
+			wantWait:      4 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:          "exponential backoff is capped",
+			err:           &Error{Kind: ErrorKindUpstream, StatusCode: 500},
+			attempt:       6,
+			wantWait:      30 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:          "retry after is honoured",
+			err:           &Error{Kind: ErrorKindRateLimited, StatusCode: 429, RetryAfter: 10 * time.Second},
+			attempt:       0,
+			wantWait:      10 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:          "retry after longer than the maximum wait is not retried",
+			err:           &Error{Kind: ErrorKindRateLimited, StatusCode: 429, RetryAfter: time.Minute},
+			attempt:       0,
+			wantWait:      time.Minute,
+			wantRetryable: false,
+		},
+		{
+			name:          "rate limited 403 with retry after is retried",
+			err:           &Error{Kind: ErrorKindRateLimited, StatusCode: 403, RetryAfter: 5 * time.Second},
+			attempt:       0,
+			wantWait:      5 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:          "rate limited 403 without retry after is not retried",
+			err:           &Error{Kind: ErrorKindRateLimited, StatusCode: 403},
+			attempt:       0,
+			wantRetryable: false,
+		},
+		{
+			name:          "rejected key is not retried",
+			err:           &Error{Kind: ErrorKindAuthFailed, StatusCode: 401},
+			attempt:       0,
+			wantRetryable: false,
+		},
+		{
+			name:          "retries are bounded",
+			err:           &Error{Kind: ErrorKindUpstream, StatusCode: 502},
+			attempt:       10,
+			wantRetryable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			wait, retryable := client.backoff(tt.err, tt.attempt)
+
+			if retryable != tt.wantRetryable {
+				t.Errorf("backoff() retryable = %v, want %v", retryable, tt.wantRetryable)
+			}
+
+			if wait != tt.wantWait {
+				t.Errorf("backoff() wait = %v, want %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestHTTPClientRequestTransportError(t *testing.T) {
+	t.Parallel()
+
+	// Find a local port nothing listens on, so that dialing it fails.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	address := listener.Addr().String()
+
+	listener.Close()
+
+	client, err := NewHTTPClient(&HTTPClientConfiguration{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("NewHTTPClient() error = %v", err)
+	}
+
+	client.retry.RetryMax = 0
+
+	client = client.WithSource("test")
+
+	_, err = client.Get(t.Context(), "http://"+address+"/api?apikey=SECRET")
+	if err == nil {
+		t.Fatal("Get() error = nil, want a transport error")
+	}
+
+	if !errors.Is(err, ErrTransport) {
+		t.Errorf("Get() error = %v, want it to wrap %v", err, ErrTransport)
+	}
+
+	if strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("Get() error = %q, want the key redacted", err.Error())
+	}
+
+	var sErr *Error
+
+	if errors.As(err, &sErr) && strings.Contains(sErr.URL, "SECRET") {
+		t.Errorf("Get() error URL = %q, want the key redacted", sErr.URL)
+	}
+}
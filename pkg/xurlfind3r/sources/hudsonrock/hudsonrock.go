@@ -8,9 +8,9 @@
 package hudsonrock
 
 import (
+	"context"
 	"encoding/json"
 
-	hqgohttp "github.com/hueristiq/hq-go-http"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
 )
 
@@ -41,6 +41,7 @@ type Source struct{}
 // Run initiates the URL discovery process for the specified domain using the Hudson Rock API.
 //
 // Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
 //   - domain (string): The target domain for which URLs are to be retrieved.
 //   - cfg (*sources.Configuration): The configuration instance containing API keys,
 //     the URL validation function, and any additional settings required by the source.
@@ -49,20 +50,20 @@ type Source struct{}
 //   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
 //     Each result is either a discovered URL (ResultURL) or an error (ResultError)
 //     encountered during the operation.
-func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sources.Result {
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
 
 		getURLsReqURL := "https://cavalier.hudsonrock.com/api/json/v2/osint-tools/urls-by-domain"
-		getURLsReqCFG := &hqgohttp.RequestConfiguration{
+		getURLsReqCFG := &sources.RequestConfiguration{
 			Params: map[string]string{
 				"domain": domain,
 			},
 		}
 
-		getURLsRes, err := hqgohttp.Get(getURLsReqURL, getURLsReqCFG)
+		getURLsRes, err := cfg.HTTPClient.Get(ctx, getURLsReqURL, getURLsReqCFG)
 		if err != nil {
 			result := sources.Result{
 				Type:   sources.ResultError,
@@ -81,7 +82,7 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 			result := sources.Result{
 				Type:   sources.ResultError,
 				Source: source.Name(),
-				Error:  err,
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, getURLsRes, err),
 			}
 
 			results <- result
@@ -93,6 +94,8 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 
 		getURLsRes.Body.Close()
 
+		cfg.Statistics.PageFetched()
+
 		for _, record := range append(getURLsResData.Data.EmployeesUrls, getURLsResData.Data.ClientsUrls...) {
 			URL := record.URL
 
@@ -123,3 +126,10 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 func (source *Source) Name() (name string) {
 	return sources.HUDSONROCK
 }
+
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.HUDSONROCK, func() sources.Source {
+		return &Source{}
+	}, sources.KeyUnused, 0)
+}
@@ -10,13 +10,15 @@ package intelx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
-	hqgohttp "github.com/hueristiq/hq-go-http"
 	hqgohttpheader "github.com/hueristiq/hq-go-http/header"
 	hqgohttpmime "github.com/hueristiq/hq-go-http/mime"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
@@ -62,8 +64,9 @@ type searchResponse struct {
 // Fields:
 //   - Selectors ([]struct): A slice of objects where each object contains a subdomain value
 //     under "selectorvalue".
-//   - Status (int): The status of the results retrieval. A value of 0 or 3 indicates that results
-//     are still being processed.
+//   - Status (int): The status of the results retrieval: 0 when results were returned and more
+//     may follow, 1 when the search is over, 2 when the search is unknown, and 3 when no results
+//     are available yet.
 type getResultsResponse struct {
 	Selectors []struct {
 		Selectvalue string `json:"selectorvalue"`
@@ -71,14 +74,21 @@ type getResultsResponse struct {
 	Status int `json:"status"`
 }
 
-// Source represents the Common Crawl data source implementation.
+// Source represents the IntelX data source implementation.
 // It implements the sources.Source interface, providing functionality
 // for retrieving URLs from the IntelX API.
 type Source struct{}
 
 // Run initiates the process of retrieving URL information from IntelX for a given domain.
 //
+// Keys are given as host:key, the host being the IntelX API host of the key's plan. Every malformed
+// key is reported as an ErrorKindMalformedKey error, and the search uses one of the others. A phonebook
+// search for up to the "max_results" setting (100000 by default) URLs is started, and its results
+// are drained by offset, polling again, at most every "poll_interval" (5s by default), while none
+// are available yet. The search is terminated once drained, failed or cancelled.
+//
 // Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
 //   - domain (string): The target domain for which URLs are to be retrieved.
 //   - cfg (*sources.Configuration): The configuration instance containing API keys,
 //     the URL validation function, and any additional settings required by the source.
@@ -87,18 +97,19 @@ type Source struct{}
 //   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
 //     Each result is either a discovered URL (ResultURL) or an error (ResultError)
 //     encountered during the operation.
-func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sources.Result {
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
 
-		key, err := cfg.Keys.IntelX.PickRandom()
-		if err != nil {
+		keys := cfg.Keys[source.Name()]
+
+		if len(keys) == 0 {
 			result := sources.Result{
 				Type:   sources.ResultError,
 				Source: source.Name(),
-				Error:  err,
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMissingKey, nil, sources.ErrNoKeys),
 			}
 
 			results <- result
@@ -106,22 +117,39 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 			return
 		}
 
-		parts := strings.Split(key, ":")
-		if len(parts) != 2 {
-			return
-		}
+		// Every key is checked up front, so that a malformed one is reported on every run,
+		// rather than only on those that happen to pick it.
+		wellFormed := sources.SourceKeys{}
+
+		for index, key := range keys {
+			if _, _, ok := parseKey(key); !ok {
+				result := sources.Result{
+					Type:   sources.ResultError,
+					Source: source.Name(),
+					Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedKey, nil, fmt.Errorf("key #%d: %w", index+1, errMalformedKey)),
+				}
 
-		intelXHost := parts[0]
-		intelXKey := parts[1]
+				results <- result
+
+				continue
+			}
 
-		if intelXKey == "" || intelXHost == "" {
+			wellFormed = append(wellFormed, key)
+		}
+
+		key, err := wellFormed.PickRandom()
+		if err != nil {
 			return
 		}
 
+		intelXHost, intelXKey, _ := parseKey(key)
+
+		maxResults := cfg.Settings[source.Name()].Int("max_results", defaultMaxResults)
+
 		searchReqURL := fmt.Sprintf("https://%s/phonebook/search?", intelXHost)
 		searchReqBody := searchRequestBody{
 			Term:       "*" + domain,
-			MaxResults: 100000,
+			MaxResults: maxResults,
 			Media:      0,
 			Target:     3, // 1 = Domains | 2 = Emails | 3 = URLs
 			Timeout:    20,
@@ -144,18 +172,18 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 
 		searchReqBodyReader := bytes.NewBuffer(searchReqBodyBytes)
 
-		searchReqCFG := &hqgohttp.RequestConfiguration{
+		searchReqCFG := &sources.RequestConfiguration{
 			Params: map[string]string{
 				"k": intelXKey,
 			},
-			Headers: []hqgohttp.Header{
-				hqgohttp.NewSetHeader(hqgohttpheader.ContentType.String(), hqgohttpmime.JSON.String()),
+			Headers: map[string]string{
+				hqgohttpheader.ContentType.String(): hqgohttpmime.JSON.String(),
 			},
 		}
 
 		var searchRes *http.Response
 
-		searchRes, err = hqgohttp.Post(searchReqURL, searchReqBodyReader, searchReqCFG)
+		searchRes, err = cfg.HTTPClient.Post(ctx, searchReqURL, searchReqBodyReader, searchReqCFG)
 		if err != nil {
 			result := sources.Result{
 				Type:   sources.ResultError,
@@ -174,7 +202,7 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 			result := sources.Result{
 				Type:   sources.ResultError,
 				Source: source.Name(),
-				Error:  err,
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, searchRes, err),
 			}
 
 			results <- result
@@ -186,20 +214,26 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 
 		searchRes.Body.Close()
 
-		getResultsReqURL := fmt.Sprintf("https://%s/phonebook/search/result", intelXHost)
-		getResultsReqCFG := &hqgohttp.RequestConfiguration{
-			Params: map[string]string{
-				"k":     intelXKey,
-				"id":    searchResData.ID,
-				"limit": "10000",
-			},
-		}
-		status := 0
+		defer source.terminate(ctx, intelXHost, intelXKey, searchResData.ID, cfg)
+
+		pollInterval := cfg.Settings[source.Name()].Duration("poll_interval", defaultPollInterval)
+
+		wait := minPollInterval
+
+		for offset := 0; offset < maxResults; {
+			getResultsReqURL := fmt.Sprintf("https://%s/phonebook/search/result", intelXHost)
+			getResultsReqCFG := &sources.RequestConfiguration{
+				Params: map[string]string{
+					"k":      intelXKey,
+					"id":     searchResData.ID,
+					"limit":  strconv.Itoa(min(resultsPerPoll, maxResults-offset)),
+					"offset": strconv.Itoa(offset),
+				},
+			}
 
-		for status == 0 || status == 3 {
 			var getResultsRes *http.Response
 
-			getResultsRes, err = hqgohttp.Get(getResultsReqURL, getResultsReqCFG)
+			getResultsRes, err = cfg.HTTPClient.Get(ctx, getResultsReqURL, getResultsReqCFG)
 			if err != nil {
 				result := sources.Result{
 					Type:   sources.ResultError,
@@ -218,7 +252,7 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 				result := sources.Result{
 					Type:   sources.ResultError,
 					Source: source.Name(),
-					Error:  err,
+					Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, getResultsRes, err),
 				}
 
 				results <- result
@@ -230,7 +264,9 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 
 			getResultsRes.Body.Close()
 
-			status = getResultsResData.Status
+			cfg.Statistics.PageFetched()
+
+			offset += len(getResultsResData.Selectors)
 
 			for _, hostname := range getResultsResData.Selectors {
 				var URL string
@@ -249,12 +285,100 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 
 				results <- result
 			}
+
+			switch getResultsResData.Status {
+			case statusResults, statusPending:
+				// More results may follow.
+			case statusDone:
+				return
+			case statusNotFound:
+				upstreamErr := sources.NewError(source.Name(), sources.ErrorKindUpstream, getResultsRes, nil)
+
+				upstreamErr.Message = fmt.Sprintf("search %s not found", searchResData.ID)
+
+				result := sources.Result{
+					Type:   sources.ResultError,
+					Source: source.Name(),
+					Error:  upstreamErr,
+				}
+
+				results <- result
+
+				return
+			}
+
+			// Results are drained as fast as they come, and polled for with a growing,
+			// bounded interval while none are available.
+			if len(getResultsResData.Selectors) > 0 {
+				wait = minPollInterval
+
+				continue
+			}
+
+			timer := time.NewTimer(wait)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return
+			case <-timer.C:
+			}
+
+			wait = min(wait*2, max(pollInterval, minPollInterval))
 		}
 	}()
 
 	return results
 }
 
+// terminate terminates a search, freeing its resources on the IntelX side. It is called once
+// the search's results are drained, or the scan is cancelled, so it does not honour ctx's
+// cancellation, but bounds its request by terminateTimeout instead.
+//
+// Parameters:
+//   - ctx (context.Context): The context of the scan.
+//   - intelXHost (string): The IntelX API host.
+//   - intelXKey (string): The IntelX API key.
+//   - ID (string): The ID of the search.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+func (source *Source) terminate(ctx context.Context, intelXHost, intelXKey, ID string, cfg *sources.Configuration) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), terminateTimeout)
+	defer cancel()
+
+	terminateReqURL := fmt.Sprintf("https://%s/intelligent/search/terminate", intelXHost)
+	terminateReqCFG := &sources.RequestConfiguration{
+		Params: map[string]string{
+			"k":  intelXKey,
+			"id": ID,
+		},
+	}
+
+	terminateRes, err := cfg.HTTPClient.Get(ctx, terminateReqURL, terminateReqCFG)
+	if err != nil {
+		return
+	}
+
+	terminateRes.Body.Close()
+}
+
+// parseKey splits a key given as host:key into the IntelX API host of the key's plan and the key itself.
+//
+// Parameters:
+//   - key (string): The key, as configured.
+//
+// Returns:
+//   - intelXHost (string): The IntelX API host.
+//   - intelXKey (string): The IntelX API key.
+//   - ok (bool): Whether the key is in the host:key format.
+func parseKey(key string) (intelXHost, intelXKey string, ok bool) {
+	intelXHost, intelXKey, ok = strings.Cut(key, ":")
+
+	ok = ok && intelXHost != "" && intelXKey != "" && !strings.Contains(intelXKey, ":")
+
+	return
+}
+
 // Name returns the unique identifier for the data source.
 // This identifier is used for logging, debugging, and associating results with the correct data source.
 //
@@ -263,3 +387,40 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 func (source *Source) Name() (name string) {
 	return sources.INTELLIGENCEX
 }
+
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.INTELLIGENCEX, func() sources.Source {
+		return &Source{}
+	}, sources.KeyRequired, 0)
+}
+
+// Constants representing the statuses of the results of a search.
+//
+// List of Constants:
+//   - statusResults: Results were returned, and more may follow.
+//   - statusDone: The search is over, and every result was returned.
+//   - statusNotFound: The search is unknown (e.g., expired or terminated).
+//   - statusPending: No results are available yet.
+const (
+	statusResults = iota
+	statusDone
+	statusNotFound
+	statusPending
+)
+
+const (
+	// defaultMaxResults is the default maximum number of results retrieved per search.
+	defaultMaxResults = 100000
+	// resultsPerPoll is the maximum number of results retrieved per poll.
+	resultsPerPoll = 1000
+	// minPollInterval is the wait before polling again after a poll without results.
+	minPollInterval = 500 * time.Millisecond
+	// defaultPollInterval is the default longest wait between polls without results.
+	defaultPollInterval = 5 * time.Second
+	// terminateTimeout is the maximum duration allowed for terminating a search.
+	terminateTimeout = 10 * time.Second
+)
+
+// errMalformedKey is returned for keys not in the host:key format.
+var errMalformedKey = errors.New("expected host:key (e.g., 2.intelx.io:00000000-0000-0000-0000-000000000000)")
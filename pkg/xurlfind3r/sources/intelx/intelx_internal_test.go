@@ -0,0 +1,231 @@
+package intelx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/internal/sourcestest"
+)
+
+// poll is a canned response to a results request.
+type poll struct {
+	selectors []string
+	status    int
+}
+
+// server is a fake IntelX API serving a single search, whose results are given as polls.
+type server struct {
+	t     *testing.T
+	polls []poll
+
+	// onPoll is called on every results request, if set.
+	onPoll func()
+
+	mu         sync.Mutex
+	offsets    []string
+	limits     []string
+	terminated []string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Host != "2.intelx.io" {
+		s.t.Errorf("request host = %s, want 2.intelx.io", r.Host)
+	}
+
+	if got := r.URL.Query().Get("k"); got != "key" {
+		s.t.Errorf("request key = %s, want key", got)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/phonebook/search":
+		w.Write([]byte(`{"id":"search-id","status":0}`))
+	case "/phonebook/search/result":
+		s.offsets = append(s.offsets, r.URL.Query().Get("offset"))
+		s.limits = append(s.limits, r.URL.Query().Get("limit"))
+
+		if s.onPoll != nil {
+			s.onPoll()
+		}
+
+		if len(s.polls) == 0 {
+			s.t.Errorf("unexpected poll at offset %s", r.URL.Query().Get("offset"))
+
+			w.Write([]byte(`{"status":1}`))
+
+			return
+		}
+
+		current := s.polls[0]
+
+		s.polls = s.polls[1:]
+
+		data := getResultsResponse{Status: current.status}
+
+		for _, selector := range current.selectors {
+			data.Selectors = append(data.Selectors, struct {
+				Selectvalue string `json:"selectorvalue"`
+			}{Selectvalue: selector})
+		}
+
+		json.NewEncoder(w).Encode(data)
+	case "/intelligent/search/terminate":
+		s.terminated = append(s.terminated, r.URL.Query().Get("id"))
+	default:
+		s.t.Errorf("unexpected request path %s", r.URL.Path)
+	}
+}
+
+func TestSourceRun(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		settings    sources.Settings
+		polls       []poll
+		wantURLs    []string
+		wantOffsets []string
+		wantLimits  []string
+		wantErr     error
+	}{
+		{
+			name: "results are drained by offset until done",
+			polls: []poll{
+				{selectors: []string{"https://example.com/a", "https://other.com/x"}, status: statusResults},
+				{status: statusPending},
+				{selectors: []string{"https://example.com/b"}, status: statusDone},
+			},
+			wantURLs:    []string{"https://example.com/a", "https://example.com/b"},
+			wantOffsets: []string{"0", "2", "2"},
+			wantLimits:  []string{"1000", "1000", "1000"},
+		},
+		{
+			name:     "draining stops at the maximum number of results",
+			settings: sources.Settings{"max_results": 3},
+			polls: []poll{
+				{selectors: []string{"https://example.com/a", "https://example.com/b"}, status: statusResults},
+				{selectors: []string{"https://example.com/c"}, status: statusResults},
+			},
+			wantURLs:    []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"},
+			wantOffsets: []string{"0", "2"},
+			wantLimits:  []string{"3", "1"},
+		},
+		{
+			name: "unknown search",
+			polls: []poll{
+				{selectors: []string{"https://example.com/a"}, status: statusResults},
+				{status: statusNotFound},
+			},
+			wantURLs:    []string{"https://example.com/a"},
+			wantOffsets: []string{"0", "1"},
+			wantLimits:  []string{"1000", "1000"},
+			wantErr:     sources.ErrUpstream,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &server{t: t, polls: tt.polls}
+
+			cfg := sourcestest.Configuration(t, "example.com", s)
+
+			cfg.Keys[sources.INTELLIGENCEX] = []string{"2.intelx.io:key"}
+			cfg.Settings[sources.INTELLIGENCEX] = tt.settings
+
+			URLs, errs := sourcestest.Run(t.Context(), &Source{}, "example.com", cfg)
+
+			if !slices.Equal(URLs, tt.wantURLs) {
+				t.Errorf("Run() URLs = %v, want %v", URLs, tt.wantURLs)
+			}
+
+			switch {
+			case tt.wantErr == nil && len(errs) > 0:
+				t.Errorf("Run() errors = %v, want none", errs)
+			case tt.wantErr != nil && (len(errs) != 1 || !errors.Is(errs[0], tt.wantErr)):
+				t.Errorf("Run() errors = %v, want a single error wrapping %v", errs, tt.wantErr)
+			}
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if !slices.Equal(s.offsets, tt.wantOffsets) {
+				t.Errorf("polled offsets = %v, want %v", s.offsets, tt.wantOffsets)
+			}
+
+			if !slices.Equal(s.limits, tt.wantLimits) {
+				t.Errorf("polled limits = %v, want %v", s.limits, tt.wantLimits)
+			}
+
+			if !slices.Equal(s.terminated, []string{"search-id"}) {
+				t.Errorf("terminated searches = %v, want [search-id]", s.terminated)
+			}
+		})
+	}
+}
+
+func TestSourceRunCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	defer cancel()
+
+	s := &server{
+		t:      t,
+		polls:  []poll{{status: statusPending}},
+		onPoll: cancel,
+	}
+
+	cfg := sourcestest.Configuration(t, "example.com", s)
+
+	cfg.Keys[sources.INTELLIGENCEX] = []string{"2.intelx.io:key"}
+
+	sourcestest.Run(ctx, &Source{}, "example.com", cfg)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The search is terminated even though the scan is cancelled.
+	if !slices.Equal(s.terminated, []string{"search-id"}) {
+		t.Errorf("terminated searches = %v, want [search-id]", s.terminated)
+	}
+}
+
+func TestSourceRunMalformedKeys(t *testing.T) {
+	t.Parallel()
+
+	s := &server{
+		t:     t,
+		polls: []poll{{selectors: []string{"https://example.com/a"}, status: statusDone}},
+	}
+
+	cfg := sourcestest.Configuration(t, "example.com", s)
+
+	cfg.Keys[sources.INTELLIGENCEX] = []string{"key-without-host", "2.intelx.io:key", ":key"}
+
+	URLs, errs := sourcestest.Run(t.Context(), &Source{}, "example.com", cfg)
+
+	if !slices.Equal(URLs, []string{"https://example.com/a"}) {
+		t.Errorf("Run() URLs = %v, want [https://example.com/a]", URLs)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("Run() errors = %v, want 2", errs)
+	}
+
+	for _, err := range errs {
+		if !errors.Is(err, sources.ErrMalformedKey) {
+			t.Errorf("Run() error = %v, want it to wrap %v", err, sources.ErrMalformedKey)
+		}
+	}
+}
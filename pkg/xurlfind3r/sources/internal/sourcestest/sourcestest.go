@@ -0,0 +1,124 @@
+// Package sourcestest provides helpers for testing sources against httptest servers.
+//
+// Sources request their upstream APIs at fixed hosts (e.g., https://www.virustotal.com). The
+// Configuration created here routes every request, whatever its host, to a single test server,
+// keeping the original host as the request's Host, so that handlers may tell the APIs apart.
+package sourcestest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	hqgourlextractor "github.com/hueristiq/hq-go-url/extractor"
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+// Transport is an http.RoundTripper that sends every request to a test server.
+//
+// Fields:
+//   - URL (*url.URL): The base URL of the test server.
+type Transport struct {
+	URL *url.URL
+}
+
+// RoundTrip sends req to the test server, keeping its original host as the request's Host.
+//
+// Parameters:
+//   - req (*http.Request): The request.
+//
+// Returns:
+//   - res (*http.Response): The test server's response.
+//   - err (error): An error if the request fails.
+func (transport *Transport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	req = req.Clone(req.Context())
+
+	req.Host = req.URL.Host
+	req.URL.Scheme = transport.URL.Scheme
+	req.URL.Host = transport.URL.Host
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// Configuration creates the configuration of a scan of domain whose requests are all served by
+// handler. The test server is closed when the test ends.
+//
+// Parameters:
+//   - t (*testing.T): The test.
+//   - domain (string): The target domain.
+//   - handler (http.Handler): The handler serving every request.
+//
+// Returns:
+//   - cfg (*sources.Configuration): The configuration, with no keys or settings.
+func Configuration(t *testing.T, domain string, handler http.Handler) (cfg *sources.Configuration) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	client, err := sources.NewHTTPClient(&sources.HTTPClientConfiguration{
+		Transport: &Transport{URL: serverURL},
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPClient() error = %v", err)
+	}
+
+	pattern := regexp.MustCompile(`^https?://([a-z0-9-]+\.)*` + regexp.QuoteMeta(domain) + `(:\d+)?(/|$)`)
+
+	cfg = &sources.Configuration{
+		Keys:     sources.Keys{},
+		Settings: map[string]sources.Settings{},
+		Extractor: hqgourlextractor.New(
+			hqgourlextractor.WithHostPattern(`(?:(?:\w+[.])*` + regexp.QuoteMeta(domain) + hqgourlextractor.ExtractorPortOptionalPattern + `)`),
+		).CompileRegex(),
+		Validate: func(target string) (URL string, valid bool) {
+			URL = target
+
+			if !strings.Contains(URL, "//") {
+				URL = "https://" + URL
+			}
+
+			valid = pattern.MatchString(URL)
+
+			return
+		},
+		HTTPClient: client,
+		Statistics: &sources.Statistics{},
+	}
+
+	return
+}
+
+// Run runs source, collecting every URL and error it reports.
+//
+// Parameters:
+//   - ctx (context.Context): The context bounding the run.
+//   - source (sources.Source): The source.
+//   - domain (string): The target domain.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//
+// Returns:
+//   - URLs ([]string): The URLs reported, in order.
+//   - errs ([]error): The errors reported, in order.
+func Run(ctx context.Context, source sources.Source, domain string, cfg *sources.Configuration) (URLs []string, errs []error) {
+	for result := range source.Run(ctx, domain, cfg) {
+		switch result.Type {
+		case sources.ResultURL:
+			URLs = append(URLs, result.Value)
+		case sources.ResultError:
+			errs = append(errs, result.Error)
+		}
+	}
+
+	return
+}
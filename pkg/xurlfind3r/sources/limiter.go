@@ -0,0 +1,86 @@
+package sources
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter paces requests so that no more than a given number are made per minute.
+// Requests are spread evenly: each one is scheduled one interval after the previous one.
+//
+// A nil *Limiter imposes no limit, so callers may use it unconditionally.
+//
+// Fields:
+//   - mutex (sync.Mutex): Guards next.
+//   - interval (time.Duration): The minimum duration between two requests.
+//   - next (time.Time): The earliest time at which the next request may be made.
+type Limiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// Wait blocks until a request may be made, or ctx is done.
+//
+// Parameters:
+//   - ctx (context.Context): The context bounding the wait.
+//
+// Returns:
+//   - err (error): The context's error if it is done before a request may be made.
+func (limiter *Limiter) Wait(ctx context.Context) (err error) {
+	if limiter == nil {
+		return
+	}
+
+	limiter.mutex.Lock()
+
+	now := time.Now()
+
+	slot := limiter.next
+
+	if slot.Before(now) {
+		slot = now
+	}
+
+	limiter.next = slot.Add(limiter.interval)
+
+	limiter.mutex.Unlock()
+
+	wait := slot.Sub(now)
+
+	if wait <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(wait)
+
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+	}
+
+	return
+}
+
+// NewLimiter creates a Limiter allowing the given number of requests per minute.
+//
+// Parameters:
+//   - requestsPerMinute (int): The maximum number of requests per minute.
+//
+// Returns:
+//   - limiter (*Limiter): The new limiter, or nil (no limit) if requestsPerMinute is not positive.
+func NewLimiter(requestsPerMinute int) (limiter *Limiter) {
+	if requestsPerMinute <= 0 {
+		return
+	}
+
+	limiter = &Limiter{
+		interval: time.Minute / time.Duration(requestsPerMinute),
+	}
+
+	return
+}
@@ -0,0 +1,80 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewLimiter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		requestsPerMinute int
+		wantNil           bool
+		wantInterval      time.Duration
+	}{
+		{requestsPerMinute: -1, wantNil: true},
+		{requestsPerMinute: 0, wantNil: true},
+		{requestsPerMinute: 1, wantInterval: time.Minute},
+		{requestsPerMinute: 4, wantInterval: 15 * time.Second},
+		{requestsPerMinute: 600, wantInterval: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		limiter := NewLimiter(tt.requestsPerMinute)
+
+		if (limiter == nil) != tt.wantNil {
+			t.Errorf("NewLimiter(%d) = %v, want nil: %v", tt.requestsPerMinute, limiter, tt.wantNil)
+
+			continue
+		}
+
+		if limiter != nil && limiter.interval != tt.wantInterval {
+			t.Errorf("NewLimiter(%d) interval = %v, want %v", tt.requestsPerMinute, limiter.interval, tt.wantInterval)
+		}
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	t.Parallel()
+
+	var unlimited *Limiter
+
+	if err := unlimited.Wait(t.Context()); err != nil {
+		t.Errorf("nil Limiter.Wait() = %v, want nil", err)
+	}
+
+	limiter := NewLimiter(1200)
+
+	started := time.Now()
+
+	for range 3 {
+		if err := limiter.Wait(t.Context()); err != nil {
+			t.Fatalf("Limiter.Wait() = %v, want nil", err)
+		}
+	}
+
+	// The first request is immediate, the next two are spaced by 50ms each.
+	if elapsed := time.Since(started); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests at 1200 per minute took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestLimiterWaitCancelled(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewLimiter(1)
+
+	if err := limiter.Wait(t.Context()); err != nil {
+		t.Fatalf("Limiter.Wait() = %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Limiter.Wait() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -9,10 +9,14 @@
 package otx
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
-	hqgohttp "github.com/hueristiq/hq-go-http"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
 	"github.com/spf13/cast"
 )
@@ -24,6 +28,7 @@ import (
 //   - URLList ([]struct): A slice of objects where each object represents a URL record.
 //     Each URL record includes:
 //   - URL (string): The discovered URL.
+//   - Date (string): The date the URL was recorded.
 //   - Domain (string): The domain associated with the URL.
 //   - Hostname (string): The hostname extracted from the URL.
 //   - Result (struct): A nested object containing additional details, including:
@@ -40,6 +45,7 @@ import (
 type getURLsResponse struct {
 	URLList []struct {
 		URL      string `json:"url"`
+		Date     string `json:"date"`
 		Domain   string `json:"domain"`
 		Hostname string `json:"hostname"`
 		Result   struct {
@@ -59,14 +65,30 @@ type getURLsResponse struct {
 	ActualSize int  `json:"actual_size"`
 }
 
-// Source represents the Common Crawl data source implementation.
+// getPassiveDNSResponse represents the structure of the JSON response returned by the OTX API
+// when querying for the passive DNS records of a domain.
+//
+// It contains the following fields:
+//   - PassiveDNS ([]struct): A slice of objects where each object represents a DNS record,
+//     including the hostname it was observed for.
+type getPassiveDNSResponse struct {
+	PassiveDNS []struct {
+		Hostname string `json:"hostname"`
+	} `json:"passive_dns"`
+}
+
+// Source represents the Open Threat Exchange data source implementation.
 // It implements the sources.Source interface, providing functionality
 // for retrieving URLs from the Open Threat Exchange API.
 type Source struct{}
 
 // Run initiates the process of retrieving URL information from Open Threat Exchange for a given domain.
 //
+// Requests are sent anonymously, or with a randomly picked key when one is configured. When subdomains
+// are in scope, the URLs recorded for every subdomain OTX's passive DNS knows of are listed as well.
+//
 // Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
 //   - domain (string): The target domain for which URLs are to be retrieved.
 //   - cfg (*sources.Configuration): The configuration instance containing API keys,
 //     the URL validation function, and any additional settings required by the source.
@@ -75,77 +97,226 @@ type Source struct{}
 //   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
 //     Each result is either a discovered URL (ResultURL) or an error (ResultError)
 //     encountered during the operation.
-func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sources.Result {
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
 
-		for page := 1; ; page++ {
-			getURLsReqURL := fmt.Sprintf("https://otx.alienvault.com/api/v1/indicators/domain/%s/url_list", domain)
-			getURLsReqCFG := &hqgohttp.RequestConfiguration{
-				Params: map[string]string{
-					"limit": "100",
-					"page":  cast.ToString(page),
-				},
+		key, err := cfg.Keys[source.Name()].PickRandom()
+		if err != nil && !errors.Is(err, sources.ErrNoKeys) {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  err,
 			}
 
-			getURLsRes, err := hqgohttp.Get(getURLsReqURL, getURLsReqCFG)
-			if err != nil {
-				result := sources.Result{
-					Type:   sources.ResultError,
-					Source: source.Name(),
-					Error:  err,
-				}
+			results <- result
 
-				results <- result
+			return
+		}
 
+		if !source.listURLs(ctx, "domain", domain, key, cfg, results) || !cfg.IncludeSubdomains {
+			return
+		}
+
+		for _, hostname := range source.hostnames(ctx, domain, key, cfg, results) {
+			if !source.listURLs(ctx, "hostname", hostname, key, cfg, results) {
 				return
 			}
+		}
+	}()
 
-			var getURLsResData getURLsResponse
+	return results
+}
 
-			if err = json.NewDecoder(getURLsRes.Body).Decode(&getURLsResData); err != nil {
-				result := sources.Result{
-					Type:   sources.ResultError,
-					Source: source.Name(),
-					Error:  err,
-				}
+// listURLs streams the in scope URLs OTX recorded for an indicator, page by page.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
+//   - indicator (string): The type of the indicator, "domain" or "hostname".
+//   - name (string): The domain or hostname.
+//   - key (string): The OTX API key, or empty for anonymous requests.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+//
+// Returns:
+//   - ok (bool): Whether listing may go on with other indicators, i.e., the scan is not cancelled.
+func (source *Source) listURLs(ctx context.Context, indicator, name, key string, cfg *sources.Configuration, results chan sources.Result) (ok bool) {
+	for page := 1; ; page++ {
+		if ctx.Err() != nil {
+			return
+		}
 
-				results <- result
+		getURLsReqURL := fmt.Sprintf("https://otx.alienvault.com/api/v1/indicators/%s/%s/url_list", indicator, url.PathEscape(name))
+		getURLsReqCFG := &sources.RequestConfiguration{
+			Params: map[string]string{
+				"limit": "100",
+				"page":  cast.ToString(page),
+			},
+			Headers: map[string]string{},
+		}
 
-				getURLsRes.Body.Close()
+		if key != "" {
+			getURLsReqCFG.Headers["X-OTX-API-KEY"] = key
+		}
 
-				return
+		getURLsRes, err := cfg.HTTPClient.Get(ctx, getURLsReqURL, getURLsReqCFG)
+		if err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  err,
+			}
+
+			results <- result
+
+			ok = ctx.Err() == nil
+
+			return
+		}
+
+		var getURLsResData getURLsResponse
+
+		if err = json.NewDecoder(getURLsRes.Body).Decode(&getURLsResData); err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, getURLsRes, err),
 			}
 
+			results <- result
+
 			getURLsRes.Body.Close()
 
-			for _, item := range getURLsResData.URLList {
-				var URL string
+			ok = true
 
-				var valid bool
+			return
+		}
 
-				if URL, valid = cfg.Validate(item.URL); !valid {
-					continue
-				}
+		getURLsRes.Body.Close()
 
-				result := sources.Result{
-					Type:   sources.ResultURL,
-					Source: source.Name(),
-					Value:  URL,
-				}
+		cfg.Statistics.PageFetched()
 
-				results <- result
+		for _, item := range getURLsResData.URLList {
+			var URL string
+
+			var valid bool
+
+			if URL, valid = cfg.Validate(item.URL); !valid {
+				continue
 			}
 
-			if !getURLsResData.HasNext {
-				break
+			metadata := &sources.Metadata{
+				StatusCode: item.HTTPCode,
+				IP:         item.Result.URLWorker.IP,
 			}
+
+			if date, err := time.Parse(dateLayout, item.Date); err == nil {
+				metadata.FirstSeen = date
+				metadata.LastSeen = date
+			}
+
+			result := sources.Result{
+				Type:     sources.ResultURL,
+				Source:   source.Name(),
+				Value:    URL,
+				Metadata: metadata,
+			}
+
+			results <- result
 		}
-	}()
 
-	return results
+		if !getURLsResData.HasNext {
+			break
+		}
+	}
+
+	ok = true
+
+	return
+}
+
+// hostnames returns the subdomains of the domain that OTX's passive DNS records, up to the
+// "max_hostnames" setting (100 by default, zero meaning no limit).
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
+//   - domain (string): The target domain.
+//   - key (string): The OTX API key, or empty for anonymous requests.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream errors to.
+//
+// Returns:
+//   - hostnames ([]string): The subdomains, in order of first record.
+func (source *Source) hostnames(ctx context.Context, domain, key string, cfg *sources.Configuration, results chan sources.Result) (hostnames []string) {
+	getPassiveDNSReqURL := fmt.Sprintf("https://otx.alienvault.com/api/v1/indicators/domain/%s/passive_dns", url.PathEscape(domain))
+	getPassiveDNSReqCFG := &sources.RequestConfiguration{
+		Headers: map[string]string{},
+	}
+
+	if key != "" {
+		getPassiveDNSReqCFG.Headers["X-OTX-API-KEY"] = key
+	}
+
+	getPassiveDNSRes, err := cfg.HTTPClient.Get(ctx, getPassiveDNSReqURL, getPassiveDNSReqCFG)
+	if err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  err,
+		}
+
+		results <- result
+
+		return
+	}
+
+	var getPassiveDNSResData getPassiveDNSResponse
+
+	if err = json.NewDecoder(getPassiveDNSRes.Body).Decode(&getPassiveDNSResData); err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, getPassiveDNSRes, err),
+		}
+
+		results <- result
+
+		getPassiveDNSRes.Body.Close()
+
+		return
+	}
+
+	getPassiveDNSRes.Body.Close()
+
+	cfg.Statistics.PageFetched()
+
+	maxHostnames := cfg.Settings[source.Name()].Int("max_hostnames", defaultMaxHostnames)
+
+	seen := map[string]struct{}{}
+
+	for _, record := range getPassiveDNSResData.PassiveDNS {
+		hostname := strings.ToLower(strings.TrimSuffix(record.Hostname, "."))
+
+		if !strings.HasSuffix(hostname, "."+domain) {
+			continue
+		}
+
+		if _, ok := seen[hostname]; ok {
+			continue
+		}
+
+		seen[hostname] = struct{}{}
+
+		hostnames = append(hostnames, hostname)
+
+		if maxHostnames > 0 && len(hostnames) >= maxHostnames {
+			break
+		}
+	}
+
+	return
 }
 
 // Name returns the unique identifier for the data source.
@@ -156,3 +327,17 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 func (source *Source) Name() (name string) {
 	return sources.OPENTHREATEXCHANGE
 }
+
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.OPENTHREATEXCHANGE, func() sources.Source {
+		return &Source{}
+	}, sources.KeyOptional, 0)
+}
+
+const (
+	// dateLayout is the layout of the dates returned by the OTX API.
+	dateLayout = "2006-01-02T15:04:05"
+	// defaultMaxHostnames is the default maximum number of subdomains whose URLs are listed.
+	defaultMaxHostnames = 100
+)
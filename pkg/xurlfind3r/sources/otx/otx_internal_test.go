@@ -0,0 +1,196 @@
+package otx
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/internal/sourcestest"
+)
+
+// server is a fake OTX API serving canned responses by request path and page, recording every
+// request and the key it was made with.
+type server struct {
+	t         *testing.T
+	responses map[string]string
+
+	mu       sync.Mutex
+	requests []string
+	keys     []string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request := r.URL.Path
+
+	if page := r.URL.Query().Get("page"); page != "" {
+		request += "?page=" + page
+	}
+
+	s.mu.Lock()
+
+	s.requests = append(s.requests, request)
+	s.keys = append(s.keys, r.Header.Get("X-OTX-API-KEY"))
+
+	s.mu.Unlock()
+
+	response, ok := s.responses[request]
+	if !ok {
+		s.t.Errorf("unexpected request %s", request)
+
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	w.Write([]byte(response))
+}
+
+func TestSourceRun(t *testing.T) {
+	t.Parallel()
+
+	responses := map[string]string{
+		"/api/v1/indicators/domain/example.com/url_list?page=1":     `{"url_list":[{"url":"https://example.com/a","date":"2024-01-02T03:04:05","httpcode":200,"result":{"urlworker":{"ip":"192.0.2.1"}}},{"url":"https://other.com/x"}],"has_next":true}`,
+		"/api/v1/indicators/domain/example.com/url_list?page=2":     `{"url_list":[{"url":"https://example.com/b"}],"has_next":false}`,
+		"/api/v1/indicators/domain/example.com/passive_dns":         `{"passive_dns":[{"hostname":"A.example.com."},{"hostname":"a.example.com"},{"hostname":"b.example.com"},{"hostname":"example.com"},{"hostname":"example.com.evil.com"}]}`,
+		"/api/v1/indicators/hostname/a.example.com/url_list?page=1": `{"url_list":[{"url":"https://a.example.com/c"}],"has_next":false}`,
+		"/api/v1/indicators/hostname/b.example.com/url_list?page=1": `{"url_list":[{"url":"https://b.example.com/d"}],"has_next":false}`,
+	}
+
+	tests := []struct {
+		name              string
+		keys              []string
+		settings          sources.Settings
+		includeSubdomains bool
+		wantURLs          []string
+		wantRequests      []string
+		wantKey           string
+	}{
+		{
+			name:     "domain pages are followed",
+			wantURLs: []string{"https://example.com/a", "https://example.com/b"},
+			wantRequests: []string{
+				"/api/v1/indicators/domain/example.com/url_list?page=1",
+				"/api/v1/indicators/domain/example.com/url_list?page=2",
+			},
+		},
+		{
+			name:              "subdomains from passive DNS are listed",
+			keys:              []string{"key"},
+			includeSubdomains: true,
+			wantURLs:          []string{"https://example.com/a", "https://example.com/b", "https://a.example.com/c", "https://b.example.com/d"},
+			wantRequests: []string{
+				"/api/v1/indicators/domain/example.com/url_list?page=1",
+				"/api/v1/indicators/domain/example.com/url_list?page=2",
+				"/api/v1/indicators/domain/example.com/passive_dns",
+				"/api/v1/indicators/hostname/a.example.com/url_list?page=1",
+				"/api/v1/indicators/hostname/b.example.com/url_list?page=1",
+			},
+			wantKey: "key",
+		},
+		{
+			name:              "subdomains are capped",
+			settings:          sources.Settings{"max_hostnames": 1},
+			includeSubdomains: true,
+			wantURLs:          []string{"https://example.com/a", "https://example.com/b", "https://a.example.com/c"},
+			wantRequests: []string{
+				"/api/v1/indicators/domain/example.com/url_list?page=1",
+				"/api/v1/indicators/domain/example.com/url_list?page=2",
+				"/api/v1/indicators/domain/example.com/passive_dns",
+				"/api/v1/indicators/hostname/a.example.com/url_list?page=1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &server{t: t, responses: responses}
+
+			cfg := sourcestest.Configuration(t, "example.com", s)
+
+			cfg.Keys[sources.OPENTHREATEXCHANGE] = tt.keys
+			cfg.Settings[sources.OPENTHREATEXCHANGE] = tt.settings
+			cfg.IncludeSubdomains = tt.includeSubdomains
+
+			URLs, errs := sourcestest.Run(t.Context(), &Source{}, "example.com", cfg)
+
+			if len(errs) > 0 {
+				t.Errorf("Run() errors = %v, want none", errs)
+			}
+
+			if !slices.Equal(URLs, tt.wantURLs) {
+				t.Errorf("Run() URLs = %v, want %v", URLs, tt.wantURLs)
+			}
+
+			if !slices.Equal(s.requests, tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", s.requests, tt.wantRequests)
+			}
+
+			for _, key := range s.keys {
+				if key != tt.wantKey {
+					t.Errorf("request key = %q, want %q", key, tt.wantKey)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceRunMetadata(t *testing.T) {
+	t.Parallel()
+
+	s := &server{t: t, responses: map[string]string{
+		"/api/v1/indicators/domain/example.com/url_list?page=1": `{"url_list":[{"url":"https://example.com/a","date":"2024-01-02T03:04:05","httpcode":200,"result":{"urlworker":{"ip":"192.0.2.1"}}}],"has_next":false}`,
+	}}
+
+	cfg := sourcestest.Configuration(t, "example.com", s)
+
+	var results []sources.Result
+
+	for result := range (&Source{}).Run(t.Context(), "example.com", cfg) {
+		results = append(results, result)
+	}
+
+	if len(results) != 1 || results[0].Metadata == nil {
+		t.Fatalf("Run() = %+v, want a single result with metadata", results)
+	}
+
+	metadata := results[0].Metadata
+
+	if date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !metadata.FirstSeen.Equal(date) || !metadata.LastSeen.Equal(date) {
+		t.Errorf("Metadata first and last seen = %v, %v, want %v", metadata.FirstSeen, metadata.LastSeen, date)
+	}
+
+	if metadata.StatusCode != 200 || metadata.IP != "192.0.2.1" {
+		t.Errorf("Metadata status code and IP = %d, %s, want 200, 192.0.2.1", metadata.StatusCode, metadata.IP)
+	}
+}
+
+func TestSourceRunMalformedPage(t *testing.T) {
+	t.Parallel()
+
+	s := &server{t: t, responses: map[string]string{
+		"/api/v1/indicators/domain/example.com/url_list?page=1":     `{`,
+		"/api/v1/indicators/domain/example.com/passive_dns":         `{"passive_dns":[{"hostname":"a.example.com"}]}`,
+		"/api/v1/indicators/hostname/a.example.com/url_list?page=1": `{"url_list":[{"url":"https://a.example.com/c"}],"has_next":false}`,
+	}}
+
+	cfg := sourcestest.Configuration(t, "example.com", s)
+
+	cfg.IncludeSubdomains = true
+
+	URLs, errs := sourcestest.Run(t.Context(), &Source{}, "example.com", cfg)
+
+	// A malformed page ends the listing of its indicator only.
+	if !slices.Equal(URLs, []string{"https://a.example.com/c"}) {
+		t.Errorf("Run() URLs = %v, want [https://a.example.com/c]", URLs)
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], sources.ErrMalformedResponse) {
+		t.Errorf("Run() errors = %v, want a single error wrapping %v", errs, sources.ErrMalformedResponse)
+	}
+}
@@ -0,0 +1,163 @@
+package sources
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory creates a new instance of a data source.
+// It is called once per Finder for every enabled source.
+//
+// Returns:
+//   - source (Source): A new instance of the data source.
+type Factory func() (source Source)
+
+// KeyRequirement describes whether a data source needs API keys to work.
+type KeyRequirement int
+
+// Constants representing whether a data source uses API keys.
+//
+// List of Constants:
+//   - KeyUnused: The source does not take API keys.
+//   - KeyOptional: The source works without API keys, but uses them when available
+//     (e.g., for higher rate limits).
+//   - KeyRequired: The source does not work without API keys.
+const (
+	KeyUnused KeyRequirement = iota
+	KeyOptional
+	KeyRequired
+)
+
+// String returns the textual representation of the key requirement.
+//
+// Returns:
+//   - requirement (string): One of "unused", "optional" or "required".
+func (requirement KeyRequirement) String() string {
+	switch requirement {
+	case KeyOptional:
+		return "optional"
+	case KeyRequired:
+		return "required"
+	case KeyUnused:
+		return "unused"
+	default:
+		return "unused"
+	}
+}
+
+// Registration describes a data source known to the registry.
+//
+// Fields:
+//   - Name (string): The unique name of the source.
+//   - Factory (Factory): The function used to create instances of the source.
+//   - Keys (KeyRequirement): Whether the source needs API keys.
+//   - RateLimit (int): The default maximum number of requests per minute, per API key for sources
+//     that take keys, zero meaning no limit.
+type Registration struct {
+	Name      string
+	Factory   Factory
+	Keys      KeyRequirement
+	RateLimit int
+}
+
+// Register makes a data source available under the provided name.
+//
+// Built-in sources register themselves when their package is imported; third-party
+// sources should do the same from an init function, so that they are picked up by
+// the Finder, the list of supported sources and the configuration file alike.
+//
+// Register panics if the name is empty, the factory is nil, or a source with the same
+// name is already registered.
+//
+// Parameters:
+//   - name (string): The unique name of the source.
+//   - factory (Factory): The function used to create instances of the source.
+//   - keys (KeyRequirement): Whether the source needs API keys.
+//   - rateLimit (int): The default maximum number of requests per minute, per API key for sources
+//     that take keys, zero meaning no limit.
+func Register(name string, factory Factory, keys KeyRequirement, rateLimit int) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if name == "" {
+		panic("sources: Register called with an empty name")
+	}
+
+	if factory == nil {
+		panic("sources: Register factory is nil for " + name)
+	}
+
+	if _, ok := registry.registrations[name]; ok {
+		panic(fmt.Sprintf("sources: Register called twice for %s", name))
+	}
+
+	registry.registrations[name] = Registration{
+		Name:      name,
+		Factory:   factory,
+		Keys:      keys,
+		RateLimit: rateLimit,
+	}
+}
+
+// Lookup returns the registration of the data source with the provided name.
+//
+// Parameters:
+//   - name (string): The name of the source.
+//
+// Returns:
+//   - registration (Registration): The registration of the source.
+//   - ok (bool): Whether a source with the provided name is registered.
+func Lookup(name string) (registration Registration, ok bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	registration, ok = registry.registrations[name]
+
+	return
+}
+
+// Registrations returns the registrations of every registered data source, sorted by name.
+//
+// Returns:
+//   - registrations ([]Registration): The registrations of the registered sources.
+func Registrations() (registrations []Registration) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	registrations = make([]Registration, 0, len(registry.registrations))
+
+	for _, registration := range registry.registrations {
+		registrations = append(registrations, registration)
+	}
+
+	sort.Slice(registrations, func(i, j int) bool {
+		return registrations[i].Name < registrations[j].Name
+	})
+
+	return
+}
+
+// Names returns the names of every registered data source, sorted.
+//
+// Returns:
+//   - names ([]string): The names of the registered sources.
+func Names() (names []string) {
+	registrations := Registrations()
+
+	names = make([]string, 0, len(registrations))
+
+	for _, registration := range registrations {
+		names = append(names, registration.Name)
+	}
+
+	return
+}
+
+// registry holds every registered data source, keyed by name.
+var registry = struct {
+	mutex         sync.RWMutex
+	registrations map[string]Registration
+}{
+	registrations: map[string]Registration{},
+}
@@ -0,0 +1,116 @@
+package sources
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	hqgohttpheader "github.com/hueristiq/hq-go-http/header"
+	hqgohttpstatus "github.com/hueristiq/hq-go-http/status"
+	"github.com/spf13/cast"
+)
+
+// isSuccessStatus reports whether code is a successful (2xx) HTTP status code.
+func isSuccessStatus(code int) bool {
+	return code >= hqgohttpstatus.OK.Int() && code < hqgohttpstatus.MultipleChoices.Int()
+}
+
+// isTransientStatus reports whether code denotes a failure worth retrying: a rate limit,
+// or a server error that is likely to go away on its own.
+func isTransientStatus(code int) bool {
+	switch code {
+	case hqgohttpstatus.TooManyRequests.Int(),
+		hqgohttpstatus.InternalServerError.Int(),
+		hqgohttpstatus.BadGateway.Int(),
+		hqgohttpstatus.ServiceUnavailable.Int(),
+		hqgohttpstatus.GatewayTimeout.Int():
+		return true
+	default:
+		return false
+	}
+}
+
+// newStatusError converts an unsuccessful response into an Error of the kind its status denotes.
+//
+// A 403 with an exhausted X-RateLimit-Remaining budget or a Retry-After header is classified as a
+// rate limit rather than a rejected key, and the time to wait before retrying is taken from the
+// Retry-After, the X-Rate-Limit-Reset-After (e.g., urlscan.io's, only read for 429 and 503, as it
+// is sent with every response) or the X-RateLimit-Reset header.
+//
+// Parameters:
+//   - source (string): The name of the source that made the request.
+//   - res (*http.Response): The unsuccessful response.
+//
+// Returns:
+//   - err (*Error): The error describing the response.
+func newStatusError(source string, res *http.Response) (err *Error) {
+	err = NewError(source, ClassifyStatus(res.StatusCode), res, nil)
+
+	exhausted := res.Header.Get(hqgohttpheader.XRatelimitRemaining.String()) == "0"
+	retryAfter := res.Header.Get(hqgohttpheader.RetryAfter.String())
+
+	if res.StatusCode == hqgohttpstatus.Forbidden.Int() && (exhausted || retryAfter != "") {
+		err.Kind = ErrorKindRateLimited
+	}
+
+	err.RetryAfter = parseRetryAfter(retryAfter)
+
+	throttled := res.StatusCode == hqgohttpstatus.TooManyRequests.Int() || res.StatusCode == hqgohttpstatus.ServiceUnavailable.Int()
+
+	if err.RetryAfter == 0 && throttled {
+		err.RetryAfter = parseRetryAfter(res.Header.Get(xRateLimitResetAfterHeader))
+	}
+
+	if err.RetryAfter == 0 && exhausted {
+		reset := cast.ToInt64(res.Header.Get(xRatelimitResetHeader))
+
+		if reset > 0 {
+			err.RetryAfter = max(time.Until(time.Unix(reset, 0)), 0)
+		}
+	}
+
+	return
+}
+
+// parseRetryAfter parses the value of a Retry-After header, given either in seconds or as an HTTP date.
+//
+// Parameters:
+//   - value (string): The header value.
+//
+// Returns:
+//   - wait (time.Duration): The duration to wait, or zero if the value is empty or invalid.
+func parseRetryAfter(value string) (wait time.Duration) {
+	if value == "" {
+		return
+	}
+
+	if seconds, err := cast.ToInt64E(value); err == nil {
+		wait = max(time.Duration(seconds)*time.Second, 0)
+
+		return
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait = max(time.Until(date), 0)
+	}
+
+	return
+}
+
+// discardBody drains up to limit bytes of the response body, so the connection can be reused, and closes it.
+//
+// Parameters:
+//   - res (*http.Response): The response whose body to discard.
+//   - limit (int64): The maximum number of bytes to drain.
+func discardBody(res *http.Response, limit int64) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, limit))
+
+	res.Body.Close()
+}
+
+const (
+	// xRatelimitResetHeader is the header carrying the Unix time at which an exhausted rate limit resets.
+	xRatelimitResetHeader = "X-Ratelimit-Reset"
+	// xRateLimitResetAfterHeader is the header carrying the number of seconds until a rate limit resets.
+	xRateLimitResetAfterHeader = "X-Rate-Limit-Reset-After"
+)
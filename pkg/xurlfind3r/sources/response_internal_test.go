@@ -0,0 +1,146 @@
+package sources
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{name: "empty", value: ""},
+		{name: "seconds", value: "120", wantMin: 2 * time.Minute, wantMax: 2 * time.Minute},
+		{name: "negative seconds", value: "-5"},
+		{name: "invalid", value: "soon"},
+		{name: "past date", value: "Wed, 21 Oct 2015 07:28:00 GMT"},
+		{
+			name:    "future date",
+			value:   time.Now().Add(time.Hour).UTC().Format(http.TimeFormat),
+			wantMin: 58 * time.Minute,
+			wantMax: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			wait := parseRetryAfter(tt.value)
+
+			if wait < tt.wantMin || wait > tt.wantMax {
+				t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, wait, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestNewStatusError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		status         int
+		headers        map[string]string
+		wantKind       ErrorKind
+		wantRetryAfter time.Duration
+	}{
+		{
+			name:     "forbidden",
+			status:   http.StatusForbidden,
+			wantKind: ErrorKindAuthFailed,
+		},
+		{
+			name:     "forbidden with exhausted budget",
+			status:   http.StatusForbidden,
+			headers:  map[string]string{"X-RateLimit-Remaining": "0"},
+			wantKind: ErrorKindRateLimited,
+		},
+		{
+			name:           "forbidden with retry after",
+			status:         http.StatusForbidden,
+			headers:        map[string]string{"Retry-After": "30"},
+			wantKind:       ErrorKindRateLimited,
+			wantRetryAfter: 30 * time.Second,
+		},
+		{
+			name:           "too many requests with reset after",
+			status:         http.StatusTooManyRequests,
+			headers:        map[string]string{"X-Rate-Limit-Reset-After": "42"},
+			wantKind:       ErrorKindRateLimited,
+			wantRetryAfter: 42 * time.Second,
+		},
+		{
+			name:           "unavailable with reset after",
+			status:         http.StatusServiceUnavailable,
+			headers:        map[string]string{"X-Rate-Limit-Reset-After": "42"},
+			wantKind:       ErrorKindUpstream,
+			wantRetryAfter: 42 * time.Second,
+		},
+		{
+			name:     "not found with reset after",
+			status:   http.StatusNotFound,
+			headers:  map[string]string{"X-Rate-Limit-Reset-After": "42"},
+			wantKind: ErrorKindUnexpected,
+		},
+		{
+			name:     "server error",
+			status:   http.StatusBadGateway,
+			wantKind: ErrorKindUpstream,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			res := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{},
+			}
+
+			for name, value := range tt.headers {
+				res.Header.Set(name, value)
+			}
+
+			err := newStatusError("test", res)
+
+			if err.Kind != tt.wantKind {
+				t.Errorf("newStatusError() kind = %v, want %v", err.Kind, tt.wantKind)
+			}
+
+			if err.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("newStatusError() retry after = %v, want %v", err.RetryAfter, tt.wantRetryAfter)
+			}
+
+			if err.StatusCode != tt.status {
+				t.Errorf("newStatusError() status code = %d, want %d", err.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestNewStatusErrorRateLimitReset(t *testing.T) {
+	t.Parallel()
+
+	res := &http.Response{
+		StatusCode: http.StatusForbidden,
+		Header:     http.Header{},
+	}
+
+	res.Header.Set("X-RateLimit-Remaining", "0")
+	res.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+
+	err := newStatusError("test", res)
+
+	if err.RetryAfter <= 58*time.Second || err.RetryAfter > time.Minute {
+		t.Errorf("newStatusError() retry after = %v, want about a minute", err.RetryAfter)
+	}
+}
@@ -0,0 +1,114 @@
+package sources
+
+import (
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// Settings holds a source's own settings (e.g., GitHub's maximum number of pages), keyed by setting name.
+//
+// Values are converted on read, so they may be given in any form cast can convert (e.g., "10m" for a
+// duration). A missing setting, or one that cannot be converted, yields the fallback provided by the
+// source, and so does any setting of a nil Settings.
+type Settings map[string]interface{}
+
+// Int returns the named setting as an int.
+//
+// Parameters:
+//   - name (string): The name of the setting.
+//   - fallback (int): The value to return if the setting is missing or invalid.
+//
+// Returns:
+//   - value (int): The setting's value, or fallback.
+func (settings Settings) Int(name string, fallback int) (value int) {
+	value = fallback
+
+	if raw, ok := settings[name]; ok && raw != nil {
+		if converted, err := cast.ToIntE(raw); err == nil {
+			value = converted
+		}
+	}
+
+	return
+}
+
+// Bool returns the named setting as a bool.
+//
+// Parameters:
+//   - name (string): The name of the setting.
+//   - fallback (bool): The value to return if the setting is missing or invalid.
+//
+// Returns:
+//   - value (bool): The setting's value, or fallback.
+func (settings Settings) Bool(name string, fallback bool) (value bool) {
+	value = fallback
+
+	if raw, ok := settings[name]; ok && raw != nil {
+		if converted, err := cast.ToBoolE(raw); err == nil {
+			value = converted
+		}
+	}
+
+	return
+}
+
+// String returns the named setting as a string.
+//
+// Parameters:
+//   - name (string): The name of the setting.
+//   - fallback (string): The value to return if the setting is missing, invalid or empty.
+//
+// Returns:
+//   - value (string): The setting's value, or fallback.
+func (settings Settings) String(name, fallback string) (value string) {
+	value = fallback
+
+	if raw, ok := settings[name]; ok && raw != nil {
+		if converted, err := cast.ToStringE(raw); err == nil && converted != "" {
+			value = converted
+		}
+	}
+
+	return
+}
+
+// Strings returns the named setting as a slice of strings.
+//
+// Parameters:
+//   - name (string): The name of the setting.
+//   - fallback ([]string): The value to return if the setting is missing or invalid.
+//
+// Returns:
+//   - value ([]string): The setting's value, or fallback.
+func (settings Settings) Strings(name string, fallback []string) (value []string) {
+	value = fallback
+
+	if raw, ok := settings[name]; ok && raw != nil {
+		if converted, err := cast.ToStringSliceE(raw); err == nil {
+			value = converted
+		}
+	}
+
+	return
+}
+
+// Duration returns the named setting as a time.Duration.
+//
+// Parameters:
+//   - name (string): The name of the setting.
+//   - fallback (time.Duration): The value to return if the setting is missing or invalid.
+//
+// Returns:
+//   - value (time.Duration): The setting's value, or fallback.
+func (settings Settings) Duration(name string, fallback time.Duration) (value time.Duration) {
+	value = fallback
+
+	if raw, ok := settings[name]; ok && raw != nil {
+		if converted, err := cast.ToDurationE(raw); err == nil {
+			value = converted
+		}
+	}
+
+	return
+}
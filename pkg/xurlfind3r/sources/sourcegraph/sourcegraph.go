@@ -0,0 +1,266 @@
+// Package sourcegraph provides an implementation of the sources.Source interface
+// for interacting with the Sourcegraph streaming search API.
+//
+// Sourcegraph indexes code from many code hosts, so searching it for occurrences of a given domain
+// can reveal URLs or references associated with that domain without a token for every host. This
+// package defines a Source type that implements the Run and Name methods as specified by the
+// sources.Source interface. The Run method streams the search results of sourcegraph.com, or of the
+// self-hosted instance set in the "url" setting, extracts URLs from the matched lines, and streams
+// discovered URLs or errors via a channel.
+package sourcegraph
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	hqgohttpheader "github.com/hueristiq/hq-go-http/header"
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+// matchesEvent represents the data of a "matches" event of the streaming search API: a batch
+// of matches. Content matches report the matched lines either as line matches or, on newer
+// instances, as chunk matches.
+type matchesEvent []struct {
+	Type        string `json:"type"`
+	LineMatches []struct {
+		Line string `json:"line"`
+	} `json:"lineMatches"`
+	ChunkMatches []struct {
+		Content string `json:"content"`
+	} `json:"chunkMatches"`
+}
+
+// errorEvent represents the data of an "error" event of the streaming search API.
+type errorEvent struct {
+	Message string `json:"message"`
+}
+
+// Source represents the Sourcegraph data source implementation.
+// It implements the sources.Source interface, providing functionality
+// for retrieving URLs by querying Sourcegraph search results.
+type Source struct{}
+
+// Run initiates the process of retrieving URL information from Sourcegraph for a given domain.
+//
+// A literal search for the domain is run against the instance set in the "url" setting
+// (https://sourcegraph.com by default), authenticated with a randomly picked access token when
+// one is configured. The search stops after the "max_results" setting (10000 by default, zero
+// meaning no limit) matches.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
+//   - domain (string): The target domain for which URLs are to be retrieved.
+//   - cfg (*sources.Configuration): The configuration instance containing API keys,
+//     the URL validation function, and any additional settings required by the source.
+//
+// Returns:
+//   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
+//     Each result is either a discovered URL (ResultURL) or an error (ResultError)
+//     encountered during the operation.
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
+	results := make(chan sources.Result)
+
+	go func() {
+		defer close(results)
+
+		instanceURL := strings.TrimSuffix(cfg.Settings[source.Name()].String("url", defaultInstanceURL), "/")
+
+		count := "all"
+
+		if maxResults := cfg.Settings[source.Name()].Int("max_results", defaultMaxResults); maxResults > 0 {
+			count = strconv.Itoa(maxResults)
+		}
+
+		searchReqURL := instanceURL + "/.api/search/stream"
+		searchReqCFG := &sources.RequestConfiguration{
+			Params: map[string]string{
+				"q": domain + " patterntype:literal count:" + count,
+			},
+			Headers: map[string]string{
+				hqgohttpheader.Accept.String(): "text/event-stream",
+			},
+		}
+
+		if key, err := cfg.Keys[source.Name()].PickRandom(); err == nil {
+			searchReqCFG.Headers[hqgohttpheader.Authorization.String()] = "token " + key
+		}
+
+		searchRes, err := cfg.HTTPClient.Get(ctx, searchReqURL, searchReqCFG)
+		if err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  err,
+			}
+
+			results <- result
+
+			return
+		}
+
+		defer searchRes.Body.Close()
+
+		cfg.Statistics.PageFetched()
+
+		scanner := bufio.NewScanner(searchRes.Body)
+
+		scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxEventSize)
+
+		var (
+			event string
+			data  []string
+		)
+
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			switch {
+			case line == "":
+				// A blank line dispatches the event.
+				if done := source.dispatch(event, strings.Join(data, "\n"), cfg, results); done {
+					return
+				}
+
+				event, data = "", nil
+			case strings.HasPrefix(line, "event:"):
+				event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			}
+		}
+
+		if err = scanner.Err(); err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  err,
+			}
+
+			results <- result
+
+			return
+		}
+
+		source.dispatch(event, strings.Join(data, "\n"), cfg, results)
+	}()
+
+	return results
+}
+
+// dispatch handles an event of the search stream, streaming the URLs found in the lines of
+// "matches" events and reporting "error" events. Other events (e.g., progress) are ignored.
+//
+// Parameters:
+//   - event (string): The event type.
+//   - data (string): The event data.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+//
+// Returns:
+//   - done (bool): Whether the stream is over, either because the search is done or failed.
+func (source *Source) dispatch(event, data string, cfg *sources.Configuration, results chan sources.Result) (done bool) {
+	switch event {
+	case "matches":
+		var matchesEventData matchesEvent
+
+		if err := json.Unmarshal([]byte(data), &matchesEventData); err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, nil, err),
+			}
+
+			results <- result
+
+			return
+		}
+
+		for _, match := range matchesEventData {
+			if match.Type != "content" {
+				continue
+			}
+
+			for _, lineMatch := range match.LineMatches {
+				source.emit(lineMatch.Line, cfg, results)
+			}
+
+			for _, chunkMatch := range match.ChunkMatches {
+				source.emit(chunkMatch.Content, cfg, results)
+			}
+		}
+	case "error":
+		var errorEventData errorEvent
+
+		_ = json.Unmarshal([]byte(data), &errorEventData)
+
+		upstreamErr := sources.NewError(source.Name(), sources.ErrorKindUpstream, nil, nil)
+
+		upstreamErr.Message = errorEventData.Message
+
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  upstreamErr,
+		}
+
+		results <- result
+
+		done = true
+	case "done":
+		done = true
+	}
+
+	return
+}
+
+// emit streams the in scope URLs found in text.
+//
+// Parameters:
+//   - text (string): The text to extract URLs from.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs to.
+func (source *Source) emit(text string, cfg *sources.Configuration, results chan sources.Result) {
+	for _, URL := range cfg.Extractor.FindAllString(text, -1) {
+		var valid bool
+
+		if URL, valid = cfg.Validate(URL); !valid {
+			continue
+		}
+
+		result := sources.Result{
+			Type:   sources.ResultURL,
+			Source: source.Name(),
+			Value:  URL,
+		}
+
+		results <- result
+	}
+}
+
+// Name returns the unique identifier for the data source.
+// This identifier is used for logging, debugging, and associating results with the correct data source.
+//
+// Returns:
+//   - name (string): The unique identifier for the data source.
+func (source *Source) Name() (name string) {
+	return sources.SOURCEGRAPH
+}
+
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.SOURCEGRAPH, func() sources.Source {
+		return &Source{}
+	}, sources.KeyOptional, 0)
+}
+
+const (
+	// defaultInstanceURL is the base URL of the Sourcegraph instance searched by default.
+	defaultInstanceURL = "https://sourcegraph.com"
+	// defaultMaxResults is the default maximum number of matches a search returns.
+	defaultMaxResults = 10000
+	// maxEventSize is the maximum size, in bytes, of a line of the search stream.
+	maxEventSize = 16 * 1024 * 1024
+)
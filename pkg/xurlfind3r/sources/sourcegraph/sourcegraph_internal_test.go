@@ -0,0 +1,108 @@
+package sourcegraph
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/internal/sourcestest"
+)
+
+func TestSourceRun(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		keys      []string
+		settings  sources.Settings
+		stream    string
+		wantQuery string
+		wantAuth  string
+		wantURLs  []string
+		wantErr   error
+	}{
+		{
+			name: "line and chunk matches",
+			stream: "event: progress\ndata: {}\n\n" +
+				"event: matches\n" +
+				`data: [{"type":"content","lineMatches":[{"line":"see https://example.com/a"}]},` +
+				`{"type":"repo"},{"type":"content","chunkMatches":[{"content":"x https://sub.example.com/b y"}]}]` + "\n\n" +
+				"event: done\ndata: {}\n\n" +
+				"event: matches\n" +
+				`data: [{"type":"content","lineMatches":[{"line":"https://example.com/after-done"}]}]` + "\n\n",
+			wantQuery: "example.com patterntype:literal count:10000",
+			wantURLs:  []string{"https://example.com/a", "https://sub.example.com/b"},
+		},
+		{
+			name:     "token and unlimited results",
+			keys:     []string{"secret"},
+			settings: sources.Settings{"max_results": 0},
+			// The last event is dispatched even without a trailing blank line.
+			stream:    "event: matches\n" + `data: [{"type":"content","lineMatches":[{"line":"https://example.com/a"}]}]`,
+			wantQuery: "example.com patterntype:literal count:all",
+			wantAuth:  "token secret",
+			wantURLs:  []string{"https://example.com/a"},
+		},
+		{
+			name:      "error event",
+			stream:    "event: error\n" + `data: {"message":"search timed out"}` + "\n\n",
+			wantQuery: "example.com patterntype:literal count:10000",
+			wantErr:   sources.ErrUpstream,
+		},
+		{
+			name: "malformed matches",
+			stream: "event: matches\ndata: [\n\n" +
+				"event: matches\n" + `data: [{"type":"content","lineMatches":[{"line":"https://example.com/a"}]}]` + "\n\n",
+			wantQuery: "example.com patterntype:literal count:10000",
+			wantURLs:  []string{"https://example.com/a"},
+			wantErr:   sources.ErrMalformedResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := sourcestest.Configuration(t, "example.com", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/.api/search/stream" {
+					t.Errorf("request path = %s, want /.api/search/stream", r.URL.Path)
+				}
+
+				if got := r.URL.Query().Get("q"); got != tt.wantQuery {
+					t.Errorf("request query = %q, want %q", got, tt.wantQuery)
+				}
+
+				if got := r.Header.Get("Authorization"); got != tt.wantAuth {
+					t.Errorf("request Authorization = %q, want %q", got, tt.wantAuth)
+				}
+
+				w.Header().Set("Content-Type", "text/event-stream")
+
+				w.Write([]byte(tt.stream))
+			}))
+
+			cfg.Keys[sources.SOURCEGRAPH] = tt.keys
+			cfg.Settings[sources.SOURCEGRAPH] = tt.settings
+
+			URLs, errs := sourcestest.Run(t.Context(), &Source{}, "example.com", cfg)
+
+			if !slices.Equal(URLs, tt.wantURLs) {
+				t.Errorf("Run() URLs = %v, want %v", URLs, tt.wantURLs)
+			}
+
+			if tt.wantErr == nil {
+				if len(errs) > 0 {
+					t.Errorf("Run() errors = %v, want none", errs)
+				}
+
+				return
+			}
+
+			if len(errs) != 1 || !errors.Is(errs[0], tt.wantErr) {
+				t.Errorf("Run() errors = %v, want a single error wrapping %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,16 +8,19 @@
 // The Result and ResultType types are used to encapsulate the outcomes of data collection operations,
 // making it easy to report successful URL discoveries or errors.
 //
-// Supported data sources are defined by a set of constants (e.g., BEVIGIL, COMMONCRAWL, GITHUB, etc.) and a
-// List slice that can be used to iterate over or validate available integrations.
+// Built-in data sources are named by a set of constants (e.g., BEVIGIL, COMMONCRAWL, GITHUB, etc.). Every
+// data source, built-in or third-party, makes itself available through Register, and the registry can be
+// queried with Lookup, Registrations and Names to iterate over or validate available integrations.
 package sources
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
 	"math/big"
 	"regexp"
+	"time"
 )
 
 // Source is the interface that every data source implementation must satisfy.
@@ -29,10 +32,12 @@ import (
 //   - Name: Returns the unique identifier (name) of the data source for logging and reporting.
 type Source interface {
 	// Run initiates the data collection or scanning process for a specified domain.
-	// The method accepts a domain name and a pointer to a Configuration instance,
+	// The method accepts a context, a domain name and a pointer to a Configuration instance,
 	// and returns a read-only channel through which results (of type Result) are streamed.
+	// Implementations must stop issuing requests and close the channel once ctx is done.
 	//
 	// Parameters:
+	//   - ctx (context.Context): The context that bounds the lifetime of the data collection.
 	//   - domain (string): A string representing the target domain for data collection.
 	//   - cfg (*Configuration): A pointer to a Configuration struct containing API keys, regular expressions,
 	//          and any other settings needed for interacting with the data source.
@@ -40,7 +45,7 @@ type Source interface {
 	// Returns:
 	//   - (<-chan Result): A read-only channel that asynchronously emits Result values,
 	//     allowing the caller to process subdomain data or errors as they become available.
-	Run(domain string, cfg *Configuration) <-chan Result
+	Run(ctx context.Context, domain string, cfg *Configuration) <-chan Result
 
 	// Name returns the unique name of the data source.
 	//
@@ -56,27 +61,28 @@ type Source interface {
 //
 // Fields:
 //   - Keys (Keys): API credentials for different data sources.
+//   - Settings (map[string]Settings): Each data source's own settings, keyed by source name.
 //   - IncludeSubdomains (bool): Whether subdomains should be considered in scope.
 //   - Extractor (*regexp.Regexp): A compiled regular expression used to extract URLs.
 //   - Validate (func(string) (string, bool)): A custom function that determines
 //     if a target is in scope and optionally transforms it.
+//   - HTTPClient (*HTTPClient): The client used to perform context-aware HTTP requests.
+//   - Statistics (*Statistics): The statistics the source reports fetched pages in. It may be nil.
 type Configuration struct {
 	Keys              Keys
+	Settings          map[string]Settings
 	IncludeSubdomains bool
 	Extractor         *regexp.Regexp
 	Validate          func(target string) (URL string, valid bool)
+	HTTPClient        *HTTPClient
+	Statistics        *Statistics
 }
 
-// Keys stores API keys for different data sources. Each field represents a collection of API keys
-// for a specific source, and is defined using the SourceKeys type (a slice of strings). These keys are
-// used for authentication when interacting with external APIs or services.
-type Keys struct {
-	Bevigil    SourceKeys `yaml:"bevigil"`
-	Github     SourceKeys `yaml:"github"`
-	IntelX     SourceKeys `yaml:"intelx"`
-	URLScan    SourceKeys `yaml:"urlscan"`
-	VirusTotal SourceKeys `yaml:"virustotal"`
-}
+// Keys stores API keys for different data sources, keyed by source name. Each entry is a collection
+// of API keys for a specific source, defined using the SourceKeys type (a slice of strings). These keys
+// are used for authentication when interacting with external APIs or services. Whether a source needs
+// keys at all is declared when it is registered (see KeyRequirement).
+type Keys map[string]SourceKeys
 
 // SourceKeys is a slice of strings where each element represents an API key for a specific source.
 // This structure supports maintaining multiple keys for a single source, which is useful for key
@@ -130,11 +136,53 @@ func (k SourceKeys) PickRandom() (key string, err error) {
 //     This field is empty if the result is an error.
 //   - Error (error): Holds the error encountered during the operation, if any. If no error
 //     occurred, this field is nil.
+//   - Metadata (*Metadata): Holds additional details about the URL as reported by the source.
+//     This field is nil if the source provides no such details or the result is an error.
+//   - Provenance (*Provenance): Holds every source that reported the URL. This field is only
+//     set on consolidated results emitted when provenance aggregation is enabled.
 type Result struct {
-	Type   ResultType
-	Source string
-	Value  string
-	Error  error
+	Type       ResultType
+	Source     string
+	Value      string
+	Error      error
+	Metadata   *Metadata
+	Provenance *Provenance
+}
+
+// Provenance records which sources reported a URL during a scan.
+//
+// Fields:
+//   - Sources ([]string): The names of the sources that reported the URL, in order of first report.
+//   - Counts (map[string]int): The number of times each source reported the URL.
+//   - FirstFound (time.Time): When the URL was first reported during the scan.
+type Provenance struct {
+	Sources    []string
+	Counts     map[string]int
+	FirstFound time.Time
+}
+
+// Metadata holds optional details about a discovered URL, as reported by the source that found it.
+// Every field is optional; zero values mean the source did not provide the information.
+//
+// Fields:
+//   - FirstSeen (time.Time): When the source first observed the URL.
+//   - LastSeen (time.Time): When the source last observed the URL.
+//   - StatusCode (int): The HTTP status code the URL responded with when observed.
+//   - MIMEType (string): The MIME type of the URL's content when observed.
+//   - Digest (string): A digest of the URL's content when observed.
+//   - IP (string): The IP address that served the URL when observed.
+//   - Surface (string): Where within the source the URL was found, for sources that search several
+//     kinds of content (e.g., "issues" for GitHub).
+//   - Captures (int): The number of times the source observed the URL (e.g., archived captures).
+type Metadata struct {
+	FirstSeen  time.Time
+	LastSeen   time.Time
+	StatusCode int
+	MIMEType   string
+	Digest     string
+	IP         string
+	Surface    string
+	Captures   int
 }
 
 // ResultType defines the category of a Result using an integer enumeration.
@@ -156,17 +204,19 @@ const (
 	ResultError
 )
 
-// Supported data source constants.
+// Built-in data source constants.
 //
-// The following constants define the names of supported data sources.
+// The following constants define the names of built-in data sources.
 // Each constant is used as a unique identifier for its corresponding data source.
 const (
 	BEVIGIL            = "bevigil"
 	COMMONCRAWL        = "commoncrawl"
 	GITHUB             = "github"
+	GITLAB             = "gitlab"
 	HUDSONROCK         = "hudsonrock"
 	INTELLIGENCEX      = "intelx"
 	OPENTHREATEXCHANGE = "otx"
+	SOURCEGRAPH        = "sourcegraph"
 	URLSCAN            = "urlscan"
 	VIRUSTOTAL         = "virustotal"
 	WAYBACK            = "wayback"
@@ -176,19 +226,3 @@ const (
 // This error is used to signal that an operation requiring an API key cannot proceed
 // because no keys are available.
 var ErrNoKeys = errors.New("no keys available for the source")
-
-// List is a collection of all supported source names.
-//
-// This slice provides a convenient way to iterate over, validate, or dynamically configure
-// the data sources available in the application.
-var List = []string{
-	BEVIGIL,
-	COMMONCRAWL,
-	GITHUB,
-	HUDSONROCK,
-	INTELLIGENCEX,
-	OPENTHREATEXCHANGE,
-	URLSCAN,
-	VIRUSTOTAL,
-	WAYBACK,
-}
@@ -0,0 +1,40 @@
+package sources
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Statistics collects counters about a single source's part of a scan.
+//
+// Sources only report the pages they fetch, via PageFetched; requests are counted by the
+// HTTPClient and every other counter is maintained by the Finder. Counters may be read at
+// any time, while Elapsed is only set once the source is done.
+//
+// Fields:
+//   - URLs (atomic.Int64): The number of URLs emitted by the Finder for the source.
+//   - Rejected (atomic.Int64): The number of candidates rejected by Configuration.Validate.
+//   - Duplicates (atomic.Int64): The number of URLs dropped because another result already carried them.
+//   - Errors (atomic.Int64): The number of errors reported by the source.
+//   - Requests (atomic.Int64): The number of HTTP requests made by the source.
+//   - Pages (atomic.Int64): The number of pages of results fetched by the source.
+//   - Elapsed (time.Duration): The time the source took to complete.
+type Statistics struct {
+	URLs       atomic.Int64
+	Rejected   atomic.Int64
+	Duplicates atomic.Int64
+	Errors     atomic.Int64
+	Requests   atomic.Int64
+	Pages      atomic.Int64
+	Elapsed    time.Duration
+}
+
+// PageFetched records that a page of results was fetched.
+// It is a no-op on a nil receiver, so sources may call it unconditionally.
+func (statistics *Statistics) PageFetched() {
+	if statistics == nil {
+		return
+	}
+
+	statistics.Pages.Add(1)
+}
@@ -5,19 +5,23 @@
 // domain, MIME type, HTTP status, and more. This package defines a Source type that
 // implements the Run and Name methods as specified by the sources.Source interface.
 // The Run method queries the urlscan.io API for URLs associated with a target domain,
-// handles pagination via the "search_after" parameter, validates discovered URLs using the
-// provided configuration, and streams valid URLs or errors asynchronously via a channel.
+// handles pagination via the "search_after" parameter, optionally retrieves the result of every
+// scan found, validates discovered URLs using the provided configuration, and streams valid URLs
+// or errors asynchronously via a channel.
 package urlscan
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
-	hqgohttp "github.com/hueristiq/hq-go-http"
 	hqgohttpheader "github.com/hueristiq/hq-go-http/header"
 	hqgohttpmime "github.com/hueristiq/hq-go-http/mime"
-	hqgohttpstatus "github.com/hueristiq/hq-go-http/status"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
 	"github.com/spf13/cast"
 )
@@ -26,18 +30,24 @@ import (
 //
 // It contains the following fields:
 //   - Results: A slice of result objects, each containing details about a scanned page.
-//     Each result includes a Page field with domain-related data and a Sort field used for pagination.
+//     Each result includes the ID of the scan, a Task field with the time of the scan, a Page field with domain-related
+//     data and a Sort field used for pagination.
 //   - Status: An integer representing the status code of the API response.
 //   - Total: An integer representing the total number of results.
 //   - Took: An integer representing the time taken for the search (in milliseconds).
 //   - HasMore: A boolean indicating whether more results are available for pagination.
 type searchResponse struct {
 	Results []struct {
+		ID   string `json:"_id"`
+		Task struct {
+			Time time.Time `json:"time"`
+		} `json:"task"`
 		Page struct {
 			Domain   string `json:"domain"`
 			MimeType string `json:"mimeType"`
 			URL      string `json:"url"`
 			Status   string `json:"status"`
+			IP       string `json:"ip"`
 		} `json:"page"`
 		Sort []interface{} `json:"sort"`
 	} `json:"results"`
@@ -47,14 +57,51 @@ type searchResponse struct {
 	HasMore bool `json:"has_more"`
 }
 
-// Source represents the Common Crawl data source implementation.
+// resultResponse represents the structure of the JSON response returned by the urlscan.io API
+// when retrieving the result of a scan.
+//
+// It contains the following fields:
+//   - Data (struct): The data recorded while loading the page, whose Requests field lists
+//     every request made, each with the request sent and the response received, if any.
+type resultResponse struct {
+	Data struct {
+		Requests []struct {
+			Request struct {
+				Request struct {
+					URL string `json:"url"`
+				} `json:"request"`
+			} `json:"request"`
+			Response struct {
+				Response struct {
+					Status          int    `json:"status"`
+					MimeType        string `json:"mimeType"`
+					RemoteIPAddress string `json:"remoteIPAddress"`
+				} `json:"response"`
+			} `json:"response"`
+		} `json:"requests"`
+	} `json:"data"`
+}
+
+// Source represents the urlscan.io data source implementation.
 // It implements the sources.Source interface, providing functionality
 // for retrieving URLs from the urlscan.io API.
 type Source struct{}
 
 // Run initiates the process of retrieving URL information from the urlscan.io API for a given domain.
 //
+// Scans are searched with each query of the "queries" setting, in which {domain} stands for the
+// target domain (e.g., "page.domain:{domain} AND date:>now-30d" or "domain:{domain} AND task.tags:phishing"),
+// "domain:{domain}" by default. Up to the "size" setting (10000 by default) scans are listed per page.
+//
+// Rate limited requests are retried after the time the rate limit headers report, up to the
+// "max_retries" setting (5 by default), as long as the wait does not exceed the "max_backoff" setting
+// (5m by default).
+//
+// With the "deep" setting, the result of every scan found is retrieved as well, and the URL of every
+// request made while loading the page (scripts, XHRs, redirects, etc.) is reported along with the page's.
+//
 // Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
 //   - domain (string): The target domain for which URLs are to be retrieved.
 //   - cfg (*sources.Configuration): The configuration instance containing API keys,
 //     the URL validation function, and any additional settings required by the source.
@@ -63,13 +110,13 @@ type Source struct{}
 //   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
 //     Each result is either a discovered URL (ResultURL) or an error (ResultError)
 //     encountered during the operation.
-func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sources.Result {
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
 
-		key, err := cfg.Keys.URLScan.PickRandom()
+		key, err := cfg.Keys[source.Name()].PickRandom()
 		if err != nil && !errors.Is(err, sources.ErrNoKeys) {
 			result := sources.Result{
 				Type:   sources.ResultError,
@@ -82,101 +129,302 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 			return
 		}
 
-		var after string
+		queries := cfg.Settings[source.Name()].Strings("queries", []string{defaultQuery})
 
-		for {
-			searchReqURL := "https://urlscan.io/api/v1/search"
-			searchReqCFG := &hqgohttp.RequestConfiguration{
-				Params: map[string]string{
-					"q":    "domain:" + domain,
-					"size": "10000",
-				},
-				Headers: []hqgohttp.Header{
-					hqgohttp.NewSetHeader(hqgohttpheader.Accept.String(), hqgohttpmime.JSON.String()),
-					hqgohttp.NewSetHeader("API-Key", key),
-				},
-			}
+		// Scans matching several queries are only retrieved once in deep mode.
+		scanned := map[string]struct{}{}
 
-			if after != "" {
-				searchReqCFG.Params["search_after"] = after
+		for _, query := range queries {
+			query = strings.ReplaceAll(query, "{domain}", domain)
+
+			if !source.search(ctx, query, key, cfg, results, scanned) {
+				return
 			}
+		}
+	}()
 
-			searchRes, err := hqgohttp.Get(searchReqURL, searchReqCFG)
-			if err != nil {
-				result := sources.Result{
-					Type:   sources.ResultError,
-					Source: source.Name(),
-					Error:  err,
-				}
+	return results
+}
 
-				results <- result
+// search lists the scans matching a query, page by page, and reports their URLs.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
+//   - query (string): The search query, in urlscan.io's search syntax.
+//   - key (string): The urlscan.io API key, or empty for anonymous requests.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+//   - scanned (map[string]struct{}): The IDs of the scans whose result was already retrieved.
+//
+// Returns:
+//   - ok (bool): Whether searching may go on with other queries, i.e., the scan is not cancelled.
+func (source *Source) search(ctx context.Context, query, key string, cfg *sources.Configuration, results chan sources.Result, scanned map[string]struct{}) (ok bool) {
+	settings := cfg.Settings[source.Name()]
+
+	deep := settings.Bool("deep", false)
+
+	var after string
+
+	for {
+		searchReqURL := "https://urlscan.io/api/v1/search"
+		searchReqCFG := &sources.RequestConfiguration{
+			Params: map[string]string{
+				"q":    query,
+				"size": strconv.Itoa(max(settings.Int("size", defaultSize), 1)),
+			},
+			Headers: map[string]string{
+				hqgohttpheader.Accept.String(): hqgohttpmime.JSON.String(),
+			},
+		}
 
-				break
+		if key != "" {
+			searchReqCFG.Headers[apiKeyHeader] = key
+		}
+
+		if after != "" {
+			searchReqCFG.Params["search_after"] = after
+		}
+
+		searchRes, err := source.get(ctx, searchReqURL, searchReqCFG, cfg)
+		if err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  err,
 			}
 
-			var searchResData searchResponse
+			results <- result
 
-			if err = json.NewDecoder(searchRes.Body).Decode(&searchResData); err != nil {
-				result := sources.Result{
-					Type:   sources.ResultError,
-					Source: source.Name(),
-					Error:  err,
-				}
+			ok = ctx.Err() == nil
 
-				results <- result
+			return
+		}
 
-				searchRes.Body.Close()
+		var searchResData searchResponse
 
-				break
+		if err = json.NewDecoder(searchRes.Body).Decode(&searchResData); err != nil {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, searchRes, err),
 			}
 
+			results <- result
+
 			searchRes.Body.Close()
 
-			if searchResData.Status == hqgohttpstatus.TooManyRequests.Int() {
-				break
-			}
+			ok = true
 
-			for _, result := range searchResData.Results {
-				var URL string
+			return
+		}
 
-				var valid bool
+		searchRes.Body.Close()
 
-				if URL, valid = cfg.Validate(result.Page.URL); !valid {
-					continue
-				}
+		cfg.Statistics.PageFetched()
 
+		for _, scan := range searchResData.Results {
+			if URL, valid := cfg.Validate(scan.Page.URL); valid {
 				result := sources.Result{
 					Type:   sources.ResultURL,
 					Source: source.Name(),
 					Value:  URL,
+					Metadata: &sources.Metadata{
+						FirstSeen:  scan.Task.Time,
+						LastSeen:   scan.Task.Time,
+						StatusCode: cast.ToInt(scan.Page.Status),
+						MIMEType:   scan.Page.MimeType,
+						IP:         scan.Page.IP,
+					},
 				}
 
 				results <- result
 			}
 
-			if !searchResData.HasMore {
-				break
+			if !deep || scan.ID == "" {
+				continue
 			}
 
-			if len(searchResData.Results) < 1 {
-				break
+			if _, exists := scanned[scan.ID]; exists {
+				continue
 			}
 
-			lastResult := searchResData.Results[len(searchResData.Results)-1]
+			scanned[scan.ID] = struct{}{}
 
-			if lastResult.Sort != nil {
-				var temp []string
+			if !source.requests(ctx, scan.ID, scan.Task.Time, key, cfg, results) {
+				return
+			}
+		}
 
-				for index := range lastResult.Sort {
-					temp = append(temp, cast.ToString(lastResult.Sort[index]))
-				}
+		if !searchResData.HasMore {
+			break
+		}
+
+		if len(searchResData.Results) < 1 {
+			break
+		}
+
+		lastResult := searchResData.Results[len(searchResData.Results)-1]
 
-				after = strings.Join(temp, ",")
+		if lastResult.Sort != nil {
+			var temp []string
+
+			for index := range lastResult.Sort {
+				temp = append(temp, cast.ToString(lastResult.Sort[index]))
 			}
+
+			after = strings.Join(temp, ",")
 		}
-	}()
+	}
 
-	return results
+	ok = true
+
+	return
+}
+
+// requests retrieves the result of a scan and reports the URL of every request made while loading the page.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
+//   - ID (string): The UUID of the scan.
+//   - seen (time.Time): The time of the scan.
+//   - key (string): The urlscan.io API key, or empty for anonymous requests.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+//
+// Returns:
+//   - ok (bool): Whether retrieval may go on with other scans, i.e., the scan is not cancelled.
+func (source *Source) requests(ctx context.Context, ID string, seen time.Time, key string, cfg *sources.Configuration, results chan sources.Result) (ok bool) {
+	getResultReqURL := "https://urlscan.io/api/v1/result/" + url.PathEscape(ID) + "/"
+	getResultReqCFG := &sources.RequestConfiguration{
+		Headers: map[string]string{
+			hqgohttpheader.Accept.String(): hqgohttpmime.JSON.String(),
+		},
+	}
+
+	if key != "" {
+		getResultReqCFG.Headers[apiKeyHeader] = key
+	}
+
+	getResultRes, err := source.get(ctx, getResultReqURL, getResultReqCFG, cfg)
+	if err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  err,
+		}
+
+		results <- result
+
+		ok = ctx.Err() == nil
+
+		return
+	}
+
+	var getResultResData resultResponse
+
+	if err = json.NewDecoder(getResultRes.Body).Decode(&getResultResData); err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, getResultRes, err),
+		}
+
+		results <- result
+
+		getResultRes.Body.Close()
+
+		ok = true
+
+		return
+	}
+
+	getResultRes.Body.Close()
+
+	cfg.Statistics.PageFetched()
+
+	for _, request := range getResultResData.Data.Requests {
+		var URL string
+
+		var valid bool
+
+		if URL, valid = cfg.Validate(request.Request.Request.URL); !valid {
+			continue
+		}
+
+		result := sources.Result{
+			Type:   sources.ResultURL,
+			Source: source.Name(),
+			Value:  URL,
+			Metadata: &sources.Metadata{
+				FirstSeen:  seen,
+				LastSeen:   seen,
+				StatusCode: request.Response.Response.Status,
+				MIMEType:   request.Response.Response.MimeType,
+				IP:         request.Response.Response.RemoteIPAddress,
+			},
+		}
+
+		results <- result
+	}
+
+	ok = true
+
+	return
+}
+
+// get sends a GET request, waiting out rate limits for as long as the rate limit headers of the
+// response report (or backing off exponentially when they report nothing), within the "max_retries"
+// and "max_backoff" settings.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the request.
+//   - URL (string): The URL to request.
+//   - reqCFG (*sources.RequestConfiguration): The configuration of the request.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//
+// Returns:
+//   - res (*http.Response): The response, whose body the caller must close.
+//   - err (error): The error the request failed with, the last rate limit included.
+func (source *Source) get(ctx context.Context, URL string, reqCFG *sources.RequestConfiguration, cfg *sources.Configuration) (res *http.Response, err error) {
+	settings := cfg.Settings[source.Name()]
+
+	maxRetries := settings.Int("max_retries", defaultMaxRetries)
+	maxBackoff := settings.Duration("max_backoff", defaultMaxBackoff)
+
+	reqCFG.HandleRateLimits = true
+
+	for attempt := 0; ; attempt++ {
+		res, err = cfg.HTTPClient.Get(ctx, URL, reqCFG)
+		if err == nil || !errors.Is(err, sources.ErrRateLimited) || attempt >= maxRetries {
+			return
+		}
+
+		var sourceErr *sources.Error
+
+		errors.As(err, &sourceErr)
+
+		wait := sourceErr.RetryAfter
+
+		if wait == 0 {
+			wait = min(minBackoff<<attempt, maxBackoff)
+		}
+
+		if wait > maxBackoff {
+			return
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			err = ctx.Err()
+
+			return
+		case <-timer.C:
+		}
+	}
 }
 
 // Name returns the unique identifier for the data source.
@@ -187,3 +435,26 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 func (source *Source) Name() (name string) {
 	return sources.URLSCAN
 }
+
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.URLSCAN, func() sources.Source {
+		return &Source{}
+	}, sources.KeyOptional, 0)
+}
+
+const (
+	// defaultQuery is the default search query, in which {domain} stands for the target domain.
+	defaultQuery = "domain:{domain}"
+	// defaultSize is the default maximum number of scans listed per page.
+	defaultSize = 10000
+	// defaultMaxRetries is the default maximum number of retries of a rate limited request.
+	defaultMaxRetries = 5
+	// defaultMaxBackoff is the default longest wait for a rate limit to reset before giving up.
+	defaultMaxBackoff = 5 * time.Minute
+	// minBackoff is the wait before the first retry of a rate limited request whose response does not
+	// report when the rate limit resets, doubled on every retry.
+	minBackoff = 10 * time.Second
+	// apiKeyHeader is the header carrying the key requests are authenticated with.
+	apiKeyHeader = "API-Key"
+)
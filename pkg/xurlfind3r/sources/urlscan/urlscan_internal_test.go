@@ -0,0 +1,241 @@
+package urlscan
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/internal/sourcestest"
+)
+
+// server is a fake urlscan.io API, recording the path and search_after of every request.
+type server struct {
+	t       *testing.T
+	handler func(w http.ResponseWriter, r *http.Request)
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request := r.URL.Path
+
+	if after := r.URL.Query().Get("search_after"); after != "" {
+		request += "?search_after=" + after
+	}
+
+	s.mu.Lock()
+
+	s.requests = append(s.requests, request)
+
+	s.mu.Unlock()
+
+	s.handler(w, r)
+}
+
+func TestSourceRunPaging(t *testing.T) {
+	t.Parallel()
+
+	s := &server{t: t, handler: func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path + "?" + r.URL.Query().Get("q") + "&" + r.URL.Query().Get("search_after") {
+		case "/api/v1/search?domain:example.com&":
+			w.Write([]byte(`{"results":[{"_id":"s1","page":{"url":"https://example.com/a"},"sort":[1,"s1"]}],"has_more":true}`))
+		case "/api/v1/search?domain:example.com&1,s1":
+			w.Write([]byte(`{"results":[{"_id":"s2","page":{"url":"https://example.com/b"},"sort":[2,"s2"]}],"has_more":false}`))
+		case "/api/v1/search?page.domain:example.com&":
+			// A scan found by both queries is retrieved once.
+			w.Write([]byte(`{"results":[{"_id":"s1","page":{"url":"https://example.com/a"}}],"has_more":false}`))
+		case "/api/v1/result/s1/?&":
+			w.Write([]byte(`{"data":{"requests":[{"request":{"request":{"url":"https://example.com/a.js"}},"response":{"response":{"status":200}}},{"request":{"request":{"url":"https://cdn.other.com/x.js"}}}]}}`))
+		case "/api/v1/result/s2/?&":
+			w.Write([]byte(`{"data":{"requests":[]}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}}
+
+	cfg := sourcestest.Configuration(t, "example.com", s)
+
+	cfg.Settings[sources.URLSCAN] = sources.Settings{
+		"queries": []string{"domain:{domain}", "page.domain:{domain}"},
+		"deep":    true,
+	}
+
+	URLs, errs := sourcestest.Run(t.Context(), &Source{}, "example.com", cfg)
+
+	if len(errs) > 0 {
+		t.Errorf("Run() errors = %v, want none", errs)
+	}
+
+	wantURLs := []string{"https://example.com/a", "https://example.com/a.js", "https://example.com/b", "https://example.com/a"}
+
+	if !slices.Equal(URLs, wantURLs) {
+		t.Errorf("Run() URLs = %v, want %v", URLs, wantURLs)
+	}
+
+	wantRequests := []string{
+		"/api/v1/search",
+		"/api/v1/result/s1/",
+		"/api/v1/search?search_after=1,s1",
+		"/api/v1/result/s2/",
+		"/api/v1/search",
+	}
+
+	if !slices.Equal(s.requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", s.requests, wantRequests)
+	}
+}
+
+func TestSourceRunKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		keys []string
+	}{
+		{
+			name: "anonymous requests carry no key",
+		},
+		{
+			name: "requests carry the key",
+			keys: []string{"key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &server{t: t, handler: func(w http.ResponseWriter, r *http.Request) {
+				values, ok := r.Header[http.CanonicalHeaderKey(apiKeyHeader)]
+
+				switch {
+				case len(tt.keys) == 0 && ok:
+					t.Errorf("request %s carries an %s header %q, want none", r.URL.Path, apiKeyHeader, values)
+				case len(tt.keys) > 0 && !slices.Equal(values, tt.keys):
+					t.Errorf("request %s %s header = %q, want %q", r.URL.Path, apiKeyHeader, values, tt.keys)
+				}
+
+				if r.URL.Path == "/api/v1/search" {
+					w.Write([]byte(`{"results":[{"_id":"s1","page":{"url":"https://example.com/a"}}],"has_more":false}`))
+
+					return
+				}
+
+				w.Write([]byte(`{"data":{"requests":[]}}`))
+			}}
+
+			cfg := sourcestest.Configuration(t, "example.com", s)
+
+			cfg.Keys[sources.URLSCAN] = tt.keys
+			cfg.Settings[sources.URLSCAN] = sources.Settings{"deep": true}
+
+			if _, errs := sourcestest.Run(t.Context(), &Source{}, "example.com", cfg); len(errs) > 0 {
+				t.Errorf("Run() errors = %v, want none", errs)
+			}
+
+			if len(s.requests) != 2 {
+				t.Errorf("requests = %v, want a search and a result", s.requests)
+			}
+		})
+	}
+}
+
+func TestSourceRunRateLimited(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		settings     sources.Settings
+		limited      int
+		resetAfter   string
+		wantURLs     []string
+		wantRequests int
+		wantWait     time.Duration
+		wantErr      error
+	}{
+		{
+			name:         "rate limit is waited out",
+			limited:      1,
+			resetAfter:   "1",
+			wantURLs:     []string{"https://example.com/a"},
+			wantRequests: 2,
+			wantWait:     time.Second,
+		},
+		{
+			name:         "retries are bounded",
+			settings:     sources.Settings{"max_retries": 1},
+			limited:      2,
+			resetAfter:   "1",
+			wantRequests: 2,
+			wantWait:     time.Second,
+			wantErr:      sources.ErrRateLimited,
+		},
+		{
+			name:         "waits longer than the maximum backoff are not attempted",
+			settings:     sources.Settings{"max_backoff": "1m"},
+			limited:      1,
+			resetAfter:   "600",
+			wantRequests: 1,
+			wantErr:      sources.ErrRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu      sync.Mutex
+				limited int
+			)
+
+			s := &server{t: t, handler: func(w http.ResponseWriter, _ *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if limited < tt.limited {
+					limited++
+
+					w.Header().Set("X-Rate-Limit-Reset-After", tt.resetAfter)
+					w.WriteHeader(http.StatusTooManyRequests)
+
+					return
+				}
+
+				w.Write([]byte(`{"results":[{"page":{"url":"https://example.com/a"}}],"has_more":false}`))
+			}}
+
+			cfg := sourcestest.Configuration(t, "example.com", s)
+
+			cfg.Settings[sources.URLSCAN] = tt.settings
+
+			started := time.Now()
+
+			URLs, errs := sourcestest.Run(t.Context(), &Source{}, "example.com", cfg)
+
+			if elapsed := time.Since(started); elapsed < tt.wantWait {
+				t.Errorf("Run() took %v, want at least %v", elapsed, tt.wantWait)
+			}
+
+			if !slices.Equal(URLs, tt.wantURLs) {
+				t.Errorf("Run() URLs = %v, want %v", URLs, tt.wantURLs)
+			}
+
+			if len(s.requests) != tt.wantRequests {
+				t.Errorf("requests = %v, want %d", s.requests, tt.wantRequests)
+			}
+
+			switch {
+			case tt.wantErr == nil && len(errs) > 0:
+				t.Errorf("Run() errors = %v, want none", errs)
+			case tt.wantErr != nil && (len(errs) != 1 || !errors.Is(errs[0], tt.wantErr)):
+				t.Errorf("Run() errors = %v, want a single error wrapping %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package virustotal
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+// getDomainReportResponse represents the structure of the JSON response returned by the VirusTotal
+// v2 API when requesting a domain report.
+//
+// It contains the following fields:
+//   - DetectedURLs ([]struct): A slice of objects, each containing a detected URL from the domain report.
+//     Each object includes:
+//   - URL (string): The URL that was detected.
+//   - ScanDate (string): The date the URL was last scanned.
+//   - UndetectedURLs ([][]interface{}): A slice of arrays where each array represents an undetected URL.
+//     The first element of each array is expected to be a string URL and the fifth its scan date.
+type getDomainReportResponse struct {
+	DetectedURLs []struct {
+		URL      string `json:"url"`
+		ScanDate string `json:"scan_date"`
+	} `json:"detected_urls"`
+	UndetectedURLs [][]interface{} `json:"undetected_urls"`
+}
+
+// runV2 retrieves URL information for a domain from the legacy v2 domain report endpoint, which
+// returns a snapshot of the URLs VirusTotal knows of, detected or not. Keys are used, set aside and
+// waited for as with the v3 API, the v2 API answering with an empty 204 once a key is rate limited.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
+//   - domain (string): The target domain for which URLs are to be retrieved.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+func (source *Source) runV2(ctx context.Context, domain string, cfg *sources.Configuration, results chan sources.Result) {
+	if len(cfg.Keys[source.Name()]) == 0 {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  sources.NewError(source.Name(), sources.ErrorKindMissingKey, nil, sources.ErrNoKeys),
+		}
+
+		results <- result
+
+		return
+	}
+
+	getDomainReportReq := request{
+		URL: "https://www.virustotal.com/vtapi/v2/domain/report",
+		params: map[string]string{
+			"domain": domain,
+		},
+		legacy: true,
+	}
+
+	data, _ := source.get(ctx, getDomainReportReq, cfg, results)
+	if data == nil {
+		return
+	}
+
+	var getDomainReportResData getDomainReportResponse
+
+	if err := json.Unmarshal(data, &getDomainReportResData); err != nil {
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, nil, err),
+		}
+
+		results <- result
+
+		return
+	}
+
+	for _, detectedURL := range getDomainReportResData.DetectedURLs {
+		var URL string
+
+		var valid bool
+
+		if URL, valid = cfg.Validate(detectedURL.URL); !valid {
+			continue
+		}
+
+		result := sources.Result{
+			Type:     sources.ResultURL,
+			Source:   source.Name(),
+			Value:    URL,
+			Metadata: parseMetadata(detectedURL.ScanDate),
+		}
+
+		results <- result
+	}
+
+	for _, undetectedURL := range getDomainReportResData.UndetectedURLs {
+		if len(undetectedURL) > 0 {
+			if URL, ok := undetectedURL[0].(string); ok {
+				var valid bool
+
+				if URL, valid = cfg.Validate(URL); !valid {
+					continue
+				}
+
+				var scanDate string
+
+				if len(undetectedURL) > 4 {
+					scanDate, _ = undetectedURL[4].(string)
+				}
+
+				result := sources.Result{
+					Type:     sources.ResultURL,
+					Source:   source.Name(),
+					Value:    URL,
+					Metadata: parseMetadata(scanDate),
+				}
+
+				results <- result
+			}
+		}
+	}
+}
+
+// parseMetadata builds result metadata from the scan date VirusTotal reports for a URL.
+//
+// Parameters:
+//   - scanDate (string): The scan date, as returned by the API.
+//
+// Returns:
+//   - metadata (*sources.Metadata): The metadata, or nil if the scan date could not be parsed.
+func parseMetadata(scanDate string) (metadata *sources.Metadata) {
+	date, err := time.Parse(scanDateLayout, scanDate)
+	if err != nil {
+		return
+	}
+
+	metadata = &sources.Metadata{
+		LastSeen: date,
+	}
+
+	return
+}
+
+// scanDateLayout is the layout of the scan dates returned by the VirusTotal v2 API.
+const scanDateLayout = "2006-01-02 15:04:05"
@@ -3,46 +3,129 @@
 //
 // The VirusTotal API aggregates threat intelligence data for domains, URLs, and files.
 // This package defines a Source type that implements the Run and Name methods as specified
-// by the sources.Source interface. The Run method retrieves URL information for a target domain
-// by querying the VirusTotal domain report endpoint, processes the detected URLs, subdomains,
-// and undetected URLs from the response, validates them using the provided configuration, and
-// streams valid URLs or errors asynchronously via a channel.
+// by the sources.Source interface. The Run method retrieves the URLs VirusTotal knows of for
+// a target domain (and, when subdomains are in scope, for its subdomains) from the v3 API,
+// paging through them by cursor, or from the legacy v2 domain report when so configured,
+// validates them using the provided configuration, and streams valid URLs or errors
+// asynchronously via a channel.
 package virustotal
 
 import (
+	"context"
 	"encoding/json"
-
-	hqgohttp "github.com/hueristiq/hq-go-http"
-	hqgolimiter "github.com/hueristiq/hq-go-limiter"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	hqgohttpheader "github.com/hueristiq/hq-go-http/header"
+	hqgohttpstatus "github.com/hueristiq/hq-go-http/status"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
 )
 
-// getDomainReportResponse represents the structure of the JSON response returned by the VirusTotal
-// API when requesting a domain report.
+// getURLsResponse represents the structure of the JSON response returned by the VirusTotal v3 API
+// for a page of a domain's URLs relationship.
 //
 // It contains the following fields:
-//   - DetectedURLs ([]struct): A slice of objects, each containing a detected URL from the domain report.
-//     Each object includes:
-//   - URL (string): The URL that was detected.
-//   - Subdomains ([]string): A slice of subdomains discovered in the domain report.
-//   - UndetectedURLs ([][]interface{}): A slice of arrays where each array represents an undetected URL.
-//     The first element of each array is expected to be a string URL.
-type getDomainReportResponse struct {
-	DetectedURLs []struct {
-		URL string `json:"url"`
-	} `json:"detected_urls"`
-	Subdomains     []string        `json:"subdomains"`
-	UndetectedURLs [][]interface{} `json:"undetected_urls"`
+//   - Data ([]struct): The URL objects of the page, each including:
+//   - URL (string): The URL.
+//   - FirstSubmissionDate (int64): When the URL was first submitted, as a Unix time.
+//   - LastAnalysisDate (int64): When the URL was last analysed, as a Unix time.
+//   - LastHTTPResponseCode (int): The HTTP status code the URL last responded with.
+//   - LastHTTPResponseHeaders (map[string]string): The headers the URL last responded with.
+//   - Meta (struct): The cursor of the next page, empty on the last page.
+type getURLsResponse struct {
+	Data []struct {
+		Attributes struct {
+			URL                     string            `json:"url"`
+			FirstSubmissionDate     int64             `json:"first_submission_date"`
+			LastAnalysisDate        int64             `json:"last_analysis_date"`
+			LastHTTPResponseCode    int               `json:"last_http_response_code"`
+			LastHTTPResponseHeaders map[string]string `json:"last_http_response_headers"`
+		} `json:"attributes"`
+	} `json:"data"`
+	Meta struct {
+		Cursor string `json:"cursor"`
+	} `json:"meta"`
 }
 
-// Source represents the Common Crawl data source implementation.
+// getSubdomainsResponse represents the structure of the JSON response returned by the VirusTotal
+// v3 API for a page of a domain's subdomains relationship descriptors.
+//
+// It contains the following fields:
+//   - Data ([]struct): The subdomains of the page, each identified by its name.
+//   - Meta (struct): The cursor of the next page, empty on the last page.
+type getSubdomainsResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+	Meta struct {
+		Cursor string `json:"cursor"`
+	} `json:"meta"`
+}
+
+// Source represents the VirusTotal data source implementation.
 // It implements the sources.Source interface, providing functionality
 // for retrieving URLs from the VirusTotal API.
-type Source struct{}
+//
+// Fields:
+//   - mutex (sync.Mutex): Guards next, blocked and restricted.
+//   - next (int): The position of the next key to use, keys being used in turn.
+//   - blocked (map[string]blockedKey): Each key set aside for being rate limited, exceeding its
+//     quota or being rejected, and until when.
+//   - restricted (map[string]struct{}): The keys known to lack access to premium endpoints.
+type Source struct {
+	mutex      sync.Mutex
+	next       int
+	blocked    map[string]blockedKey
+	restricted map[string]struct{}
+}
+
+// blockedKey describes a key set aside.
+//
+// Fields:
+//   - until (time.Time): The time until which the key must not be used.
+//   - rateLimited (bool): Whether the key was only rate limited, rather than out of quota or rejected,
+//     i.e., whether it is worth waiting for.
+type blockedKey struct {
+	until       time.Time
+	rateLimited bool
+}
+
+// request describes a request to the VirusTotal API.
+//
+// Fields:
+//   - URL (string): The API endpoint.
+//   - params (map[string]string): The query parameters of the request.
+//   - legacy (bool): Whether the endpoint belongs to the v2 API, which takes the key as the "apikey"
+//     query parameter rather than the "x-apikey" header.
+//   - premium (bool): Whether the endpoint is only available to premium keys, a 403 then meaning that
+//     the key lacks access rather than that it is rejected.
+type request struct {
+	URL     string
+	params  map[string]string
+	legacy  bool
+	premium bool
+}
 
 // Run initiates the process of retrieving URL information from the VirusTotal API for a given domain.
 //
+// By default, the domain's URLs are listed from the v3 API, and so are those of every subdomain
+// listed in the domain's subdomains relationship when subdomains are in scope, each listing up to
+// the "max_pages" setting (10 by default, zero meaning no limit) pages. Keys are used in turn, and
+// a key that is rate limited, exceeds its quota or is rejected is set aside for a while, the request
+// being retried with the next one. When every key is only rate limited, the request waits for the
+// first to be usable again, for up to the "max_wait" setting (5m by default) in total. The URLs
+// relationship is premium only: keys denied it are reported once, and listing is skipped when no
+// key has access. Setting "api" to v2 uses the legacy v2 domain report instead.
+//
 // Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
 //   - domain (string): The target domain for which URLs are to be retrieved.
 //   - cfg (*sources.Configuration): The configuration instance containing API keys,
 //     the URL validation function, and any additional settings required by the source.
@@ -51,18 +134,23 @@ type Source struct{}
 //   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
 //     Each result is either a discovered URL (ResultURL) or an error (ResultError)
 //     encountered during the operation.
-func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sources.Result {
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
 
-		key, err := cfg.Keys.VirusTotal.PickRandom()
-		if err != nil {
+		if cfg.Settings[source.Name()].String("api", apiV3) == apiV2 {
+			source.runV2(ctx, domain, cfg, results)
+
+			return
+		}
+
+		if len(cfg.Keys[source.Name()]) == 0 {
 			result := sources.Result{
 				Type:   sources.ResultError,
 				Source: source.Name(),
-				Error:  err,
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMissingKey, nil, sources.ErrNoKeys),
 			}
 
 			results <- result
@@ -70,22 +158,160 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 			return
 		}
 
-		getDomainReportReqURL := "https://www.virustotal.com/vtapi/v2/domain/report"
-		getDomainReportReqCFG := &hqgohttp.RequestConfiguration{
-			Params: map[string]string{
-				"apikey": key,
-				"domain": domain,
-			},
+		if !source.listURLs(ctx, domain, cfg, results) || !cfg.IncludeSubdomains {
+			return
+		}
+
+		// Listing the subdomains is pointless when no key may list their URLs.
+		if !source.accessible(cfg.Keys[source.Name()]) {
+			return
+		}
+
+		getSubdomainsReq := request{
+			URL: "https://www.virustotal.com/api/v3/domains/" + url.PathEscape(domain) + "/relationships/subdomains",
+		}
+
+		source.paginate(ctx, getSubdomainsReq, cfg, results, func(data []byte) (cursor string, stop bool, err error) {
+			var getSubdomainsResData getSubdomainsResponse
+
+			if err = json.Unmarshal(data, &getSubdomainsResData); err != nil {
+				return
+			}
+
+			for _, subdomain := range getSubdomainsResData.Data {
+				if !source.listURLs(ctx, subdomain.ID, cfg, results) {
+					stop = true
+
+					return
+				}
+			}
+
+			cursor = getSubdomainsResData.Meta.Cursor
+
+			return
+		})
+	}()
+
+	return results
+}
+
+// listURLs streams the in scope URLs of a domain's URLs relationship.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
+//   - domain (string): The domain whose URLs are listed.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream discovered URLs or errors.
+//
+// Returns:
+//   - ok (bool): Whether listing may go on with other domains, i.e., the scan is not cancelled
+//     and keys are left.
+func (source *Source) listURLs(ctx context.Context, domain string, cfg *sources.Configuration, results chan sources.Result) (ok bool) {
+	getURLsReq := request{
+		URL:     "https://www.virustotal.com/api/v3/domains/" + url.PathEscape(domain) + "/urls",
+		premium: true,
+	}
+
+	return source.paginate(ctx, getURLsReq, cfg, results, func(data []byte) (cursor string, stop bool, err error) {
+		var getURLsResData getURLsResponse
+
+		if err = json.Unmarshal(data, &getURLsResData); err != nil {
+			return
+		}
+
+		for _, item := range getURLsResData.Data {
+			var URL string
+
+			var valid bool
+
+			if URL, valid = cfg.Validate(item.Attributes.URL); !valid {
+				continue
+			}
+
+			metadata := &sources.Metadata{
+				StatusCode: item.Attributes.LastHTTPResponseCode,
+			}
+
+			if item.Attributes.FirstSubmissionDate > 0 {
+				metadata.FirstSeen = time.Unix(item.Attributes.FirstSubmissionDate, 0).UTC()
+			}
+
+			if item.Attributes.LastAnalysisDate > 0 {
+				metadata.LastSeen = time.Unix(item.Attributes.LastAnalysisDate, 0).UTC()
+			}
+
+			for name, value := range item.Attributes.LastHTTPResponseHeaders {
+				if http.CanonicalHeaderKey(name) != hqgohttpheader.ContentType.String() {
+					continue
+				}
+
+				if mediaType, _, err := mime.ParseMediaType(value); err == nil {
+					metadata.MIMEType = mediaType
+				}
+			}
+
+			result := sources.Result{
+				Type:     sources.ResultURL,
+				Source:   source.Name(),
+				Value:    URL,
+				Metadata: metadata,
+			}
+
+			results <- result
 		}
 
-		limiter.Wait()
+		cursor = getURLsResData.Meta.Cursor
+
+		return
+	})
+}
+
+// paginate walks the pages of a v3 API collection by cursor, up to the "max_pages" setting, handing
+// each page to process.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
+//   - req (request): The request for the collection's first page.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream errors to.
+//   - process (func(data []byte) (cursor string, stop bool, err error)): Processes a page, returning
+//     the cursor of the next page, whether walking must stop (e.g., the scan is cancelled), and an
+//     error if the page could not be decoded.
+//
+// Returns:
+//   - ok (bool): Whether walking may go on with other collections, i.e., the scan is not cancelled
+//     and keys are left.
+func (source *Source) paginate(ctx context.Context, req request, cfg *sources.Configuration, results chan sources.Result, process func(data []byte) (cursor string, stop bool, err error)) (ok bool) {
+	maxPages := cfg.Settings[source.Name()].Int("max_pages", defaultMaxPages)
+
+	cursor := ""
 
-		getDomainReportRes, err := hqgohttp.Get(getDomainReportReqURL, getDomainReportReqCFG)
+	for page := 0; maxPages <= 0 || page < maxPages; page++ {
+		req.params = map[string]string{
+			"limit": strconv.Itoa(perPage),
+		}
+
+		if cursor != "" {
+			req.params["cursor"] = cursor
+		}
+
+		var (
+			data []byte
+			stop bool
+			err  error
+		)
+
+		data, ok = source.get(ctx, req, cfg, results)
+		if !ok || data == nil {
+			return
+		}
+
+		cursor, stop, err = process(data)
 		if err != nil {
 			result := sources.Result{
 				Type:   sources.ResultError,
 				Source: source.Name(),
-				Error:  err,
+				Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, nil, fmt.Errorf("%w: %w", errMalformedPage, err)),
 			}
 
 			results <- result
@@ -93,82 +319,323 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 			return
 		}
 
-		var getDomainReportResData getDomainReportResponse
+		if stop {
+			ok = false
+
+			return
+		}
+
+		if cursor == "" || ctx.Err() != nil {
+			break
+		}
+	}
+
+	ok = ctx.Err() == nil
+
+	return
+}
+
+// get performs a GET request to the API, authenticated with the keys in turn, moving on to the
+// next key whenever one is rate limited, exceeds its quota, is rejected or, for premium endpoints,
+// lacks access, and waiting, within the "max_wait" setting, for a key to be usable again when every
+// key is only rate limited.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the request.
+//   - req (request): The request.
+//   - cfg (*sources.Configuration): The configuration of the scan.
+//   - results (chan sources.Result): A channel to stream errors to.
+//
+// Returns:
+//   - data ([]byte): The response body, or nil if the request failed (e.g., the domain is unknown)
+//     or, for premium endpoints, no key has access.
+//   - ok (bool): Whether requests may go on, i.e., the scan is not cancelled and keys are left.
+//     Errors are reported on results.
+func (source *Source) get(ctx context.Context, req request, cfg *sources.Configuration, results chan sources.Result) (data []byte, ok bool) {
+	keys := cfg.Keys[source.Name()]
+
+	maxWait := cfg.Settings[source.Name()].Duration("max_wait", defaultMaxWait)
+
+	var waited time.Duration
+
+	for {
+		if req.premium && !source.accessible(keys) {
+			ok = true
+
+			return
+		}
+
+		key, wait, available := source.key(keys, req.premium)
+		if !available && wait > 0 && waited+wait <= maxWait {
+			timer := time.NewTimer(wait)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return
+			case <-timer.C:
+			}
+
+			waited += wait
+
+			continue
+		}
+
+		if !available && wait > 0 {
+			rateLimitedErr := sources.NewError(source.Name(), sources.ErrorKindRateLimited, nil, errKeysRateLimited)
+
+			rateLimitedErr.RetryAfter = wait
 
-		if err = json.NewDecoder(getDomainReportRes.Body).Decode(&getDomainReportResData); err != nil {
 			result := sources.Result{
 				Type:   sources.ResultError,
 				Source: source.Name(),
-				Error:  err,
+				Error:  rateLimitedErr,
 			}
 
 			results <- result
 
-			getDomainReportRes.Body.Close()
+			return
+		}
+
+		if !available {
+			result := sources.Result{
+				Type:   sources.ResultError,
+				Source: source.Name(),
+				Error:  sources.NewError(source.Name(), sources.ErrorKindQuotaExhausted, nil, errNoKeysLeft),
+			}
+
+			results <- result
 
 			return
 		}
 
-		getDomainReportRes.Body.Close()
+		reqCFG := &sources.RequestConfiguration{
+			Params: map[string]string{},
+			Headers: map[string]string{
+				"x-apikey": key,
+			},
+			HandleRateLimits: true,
+		}
 
-		for _, detectedURL := range getDomainReportResData.DetectedURLs {
-			var URL string
+		for name, value := range req.params {
+			reqCFG.Params[name] = value
+		}
 
-			var valid bool
+		if req.legacy {
+			reqCFG.Params["apikey"] = key
+			reqCFG.Headers = nil
+		}
+
+		res, err := cfg.HTTPClient.Get(ctx, req.URL, reqCFG)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			var sourceErr *sources.Error
+
+			errors.As(err, &sourceErr)
+
+			switch {
+			case errors.Is(err, sources.ErrRateLimited):
+				source.block(key, time.Now().Add(max(sourceErr.RetryAfter, rateLimitCooldown)), true)
+
+				continue
+			case errors.Is(err, sources.ErrQuotaExhausted):
+				source.block(key, time.Now().Add(max(sourceErr.RetryAfter, quotaCooldown)), false)
+
+				continue
+			case req.premium && sourceErr != nil && sourceErr.StatusCode == hqgohttpstatus.Forbidden.Int():
+				if !source.restrict(key) {
+					continue
+				}
+
+				restrictedErr := sources.NewError(source.Name(), sources.ErrorKindAuthFailed, nil, errPremiumOnly)
+
+				restrictedErr.StatusCode = sourceErr.StatusCode
+				restrictedErr.URL = sourceErr.URL
+
+				result := sources.Result{
+					Type:   sources.ResultError,
+					Source: source.Name(),
+					Error:  restrictedErr,
+				}
+
+				results <- result
+
+				continue
+			case errors.Is(err, sources.ErrAuthFailed):
+				source.block(key, time.Now().Add(rejectedCooldown), false)
+
+				result := sources.Result{
+					Type:   sources.ResultError,
+					Source: source.Name(),
+					Error:  err,
+				}
+
+				results <- result
 
-			if URL, valid = cfg.Validate(detectedURL.URL); !valid {
 				continue
 			}
 
 			result := sources.Result{
-				Type:   sources.ResultURL,
+				Type:   sources.ResultError,
 				Source: source.Name(),
-				Value:  URL,
+				Error:  err,
 			}
 
 			results <- result
+
+			ok = true
+
+			return
 		}
 
-		for _, subdomain := range getDomainReportResData.Subdomains {
-			var URL string
+		// The v2 API answers with an empty 204 once the key's request rate is exceeded.
+		if res.StatusCode == hqgohttpstatus.NoContent.Int() {
+			res.Body.Close()
 
-			var valid bool
+			source.block(key, time.Now().Add(rateLimitCooldown), true)
 
-			if URL, valid = cfg.Validate(subdomain); !valid {
-				continue
-			}
+			continue
+		}
+
+		data, err = io.ReadAll(res.Body)
 
+		res.Body.Close()
+
+		if err != nil {
 			result := sources.Result{
-				Type:   sources.ResultURL,
+				Type:   sources.ResultError,
 				Source: source.Name(),
-				Value:  URL,
+				Error:  err,
 			}
 
 			results <- result
+
+			data, ok = nil, true
+
+			return
 		}
 
-		for _, undetectedURL := range getDomainReportResData.UndetectedURLs {
-			if len(undetectedURL) > 0 {
-				if URL, ok := undetectedURL[0].(string); ok {
-					var valid bool
+		cfg.Statistics.PageFetched()
 
-					if URL, valid = cfg.Validate(URL); !valid {
-						continue
-					}
+		ok = true
 
-					result := sources.Result{
-						Type:   sources.ResultURL,
-						Source: source.Name(),
-						Value:  URL,
-					}
+		return
+	}
+}
 
-					results <- result
-				}
+// key returns the next key to use, skipping keys set aside and, for premium endpoints, keys that
+// lack access.
+//
+// Parameters:
+//   - keys ([]string): The configured keys.
+//   - premium (bool): Whether the key is for a premium endpoint.
+//
+// Returns:
+//   - key (string): The key to use.
+//   - wait (time.Duration): When no key is available, how long until the first rate limited key is
+//     usable again, or zero if no key is only rate limited.
+//   - available (bool): Whether a key is available.
+func (source *Source) key(keys []string, premium bool) (key string, wait time.Duration, available bool) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	now := time.Now()
+
+	for range keys {
+		candidate := keys[source.next%len(keys)]
+
+		source.next++
+
+		if _, restricted := source.restricted[candidate]; premium && restricted {
+			continue
+		}
+
+		blocked, ok := source.blocked[candidate]
+		if ok && blocked.until.After(now) {
+			if blocked.rateLimited && (wait == 0 || blocked.until.Sub(now) < wait) {
+				wait = blocked.until.Sub(now)
 			}
+
+			continue
 		}
-	}()
 
-	return results
+		key, wait, available = candidate, 0, true
+
+		return
+	}
+
+	return
+}
+
+// block sets a key aside until the provided time.
+//
+// Parameters:
+//   - key (string): The key.
+//   - until (time.Time): The time until which the key must not be used.
+//   - rateLimited (bool): Whether the key is only rate limited, rather than out of quota or rejected.
+func (source *Source) block(key string, until time.Time, rateLimited bool) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.blocked == nil {
+		source.blocked = map[string]blockedKey{}
+	}
+
+	source.blocked[key] = blockedKey{
+		until:       until,
+		rateLimited: rateLimited,
+	}
+}
+
+// restrict records that a key lacks access to premium endpoints.
+//
+// Parameters:
+//   - key (string): The key.
+//
+// Returns:
+//   - recorded (bool): Whether the key was not known to lack access yet.
+func (source *Source) restrict(key string) (recorded bool) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.restricted == nil {
+		source.restricted = map[string]struct{}{}
+	}
+
+	if _, ok := source.restricted[key]; ok {
+		return
+	}
+
+	source.restricted[key] = struct{}{}
+
+	recorded = true
+
+	return
+}
+
+// accessible reports whether any of the keys may have access to premium endpoints, i.e., is not
+// known to lack it.
+//
+// Parameters:
+//   - keys ([]string): The configured keys.
+//
+// Returns:
+//   - accessible (bool): Whether any key may have access.
+func (source *Source) accessible(keys []string) (accessible bool) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	for _, key := range keys {
+		if _, ok := source.restricted[key]; !ok {
+			return true
+		}
+	}
+
+	return
 }
 
 // Name returns the unique identifier for the data source.
@@ -180,10 +647,42 @@ func (source *Source) Name() (name string) {
 	return sources.VIRUSTOTAL
 }
 
-// limiter is a rate limiter instance configured to control the number of requests
-// sent to the VirusTotal API. It ensures that no more than 4 requests are made per minute,
-// with a minimum delay of 30 seconds between requests.
-var limiter = hqgolimiter.New(&hqgolimiter.Configuration{
-	RequestsPerMinute:     4,
-	MinimumDelayInSeconds: 30,
-})
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.VIRUSTOTAL, func() sources.Source {
+		return &Source{}
+	}, sources.KeyRequired, 4)
+}
+
+const (
+	// apiV2 and apiV3 are the values of the "api" setting selecting the API version used.
+	apiV2 = "v2"
+	apiV3 = "v3"
+	// perPage is the number of objects requested per page of a v3 collection, the API's maximum.
+	perPage = 40
+	// defaultMaxPages is the default maximum number of pages fetched per collection.
+	defaultMaxPages = 10
+	// rateLimitCooldown is how long a rate limited key is set aside, unless the API asks for longer.
+	rateLimitCooldown = time.Minute
+	// quotaCooldown is how long a key that exceeded its (daily or monthly) quota is set aside, unless
+	// the API asks for longer.
+	quotaCooldown = 24 * time.Hour
+	// defaultMaxWait is the default longest time a request waits, in total, for a rate limited key.
+	defaultMaxWait = 5 * time.Minute
+	// rejectedCooldown is how long a rejected key is set aside.
+	rejectedCooldown = 24 * time.Hour
+)
+
+var (
+	// errNoKeysLeft is returned when every key exceeded its quota or was rejected.
+	errNoKeysLeft = errors.New("every key exceeded its quota or was rejected")
+
+	// errKeysRateLimited is returned when every key is rate limited for longer than the "max_wait" setting allows.
+	errKeysRateLimited = errors.New("every key is rate limited")
+
+	// errMalformedPage is returned for pages of a v3 collection that cannot be decoded.
+	errMalformedPage = errors.New("malformed page")
+
+	// errPremiumOnly is returned, once per key, for keys denied a premium endpoint (e.g., a domain's URLs).
+	errPremiumOnly = errors.New("relationship not available for this key (premium only)")
+)
@@ -0,0 +1,339 @@
+package virustotal
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/internal/sourcestest"
+)
+
+// server is a fake VirusTotal API, recording the key and path of every request.
+type server struct {
+	t       *testing.T
+	handler func(w http.ResponseWriter, r *http.Request, key string)
+
+	mu       sync.Mutex
+	keys     []string
+	requests []string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("x-apikey")
+
+	if key == "" {
+		key = r.URL.Query().Get("apikey")
+	}
+
+	s.mu.Lock()
+
+	s.keys = append(s.keys, key)
+
+	request := r.URL.Path
+
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		request += "?cursor=" + cursor
+	}
+
+	s.requests = append(s.requests, request)
+
+	s.mu.Unlock()
+
+	s.handler(w, r, key)
+}
+
+// run runs source against s, with the given keys and settings.
+func (s *server) run(t *testing.T, source *Source, keys []string, settings sources.Settings, includeSubdomains bool) (URLs []string, errs []error) {
+	t.Helper()
+
+	cfg := sourcestest.Configuration(t, "example.com", s)
+
+	cfg.Keys[sources.VIRUSTOTAL] = keys
+	cfg.Settings[sources.VIRUSTOTAL] = settings
+	cfg.IncludeSubdomains = includeSubdomains
+
+	return sourcestest.Run(t.Context(), source, "example.com", cfg)
+}
+
+func TestSourceRunPaging(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		settings     sources.Settings
+		wantURLs     []string
+		wantRequests []string
+	}{
+		{
+			name:         "pages are followed by cursor",
+			wantURLs:     []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"},
+			wantRequests: []string{"/api/v3/domains/example.com/urls", "/api/v3/domains/example.com/urls?cursor=c2"},
+		},
+		{
+			name:         "paging stops at the maximum number of pages",
+			settings:     sources.Settings{"max_pages": 1},
+			wantURLs:     []string{"https://example.com/a", "https://example.com/b"},
+			wantRequests: []string{"/api/v3/domains/example.com/urls"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &server{t: t, handler: func(w http.ResponseWriter, r *http.Request, _ string) {
+				if got := r.URL.Query().Get("limit"); got != "40" {
+					t.Errorf("request limit = %s, want 40", got)
+				}
+
+				switch r.URL.Query().Get("cursor") {
+				case "":
+					w.Write([]byte(`{"data":[{"attributes":{"url":"https://example.com/a"}},{"attributes":{"url":"https://other.com/x"}},{"attributes":{"url":"https://example.com/b"}}],"meta":{"cursor":"c2"}}`))
+				case "c2":
+					w.Write([]byte(`{"data":[{"attributes":{"url":"https://example.com/c"}}],"meta":{}}`))
+				}
+			}}
+
+			URLs, errs := s.run(t, &Source{}, []string{"k1"}, tt.settings, false)
+
+			if len(errs) > 0 {
+				t.Errorf("Run() errors = %v, want none", errs)
+			}
+
+			if !slices.Equal(URLs, tt.wantURLs) {
+				t.Errorf("Run() URLs = %v, want %v", URLs, tt.wantURLs)
+			}
+
+			if !slices.Equal(s.requests, tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", s.requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestSourceRunKeyRotation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		keys     []string
+		settings sources.Settings
+		statuses map[string]int
+		wantURLs []string
+		wantKeys []string
+		wantErrs []error
+	}{
+		{
+			name:     "rate limited key is set aside",
+			keys:     []string{"k1", "k2"},
+			statuses: map[string]int{"k1": http.StatusTooManyRequests},
+			wantURLs: []string{"https://example.com/a", "https://example.com/b"},
+			wantKeys: []string{"k1", "k2", "k2"},
+		},
+		{
+			name:     "key out of quota is set aside",
+			keys:     []string{"k1", "k2"},
+			statuses: map[string]int{"k1": http.StatusPaymentRequired},
+			wantURLs: []string{"https://example.com/a", "https://example.com/b"},
+			wantKeys: []string{"k1", "k2", "k2"},
+		},
+		{
+			name:     "rejected key is reported and set aside",
+			keys:     []string{"k1", "k2"},
+			statuses: map[string]int{"k1": http.StatusUnauthorized},
+			wantURLs: []string{"https://example.com/a", "https://example.com/b"},
+			wantKeys: []string{"k1", "k2", "k2"},
+			wantErrs: []error{sources.ErrAuthFailed},
+		},
+		{
+			name:     "every key rate limited beyond the maximum wait",
+			keys:     []string{"k1", "k2"},
+			settings: sources.Settings{"max_wait": "0s"},
+			statuses: map[string]int{"k1": http.StatusTooManyRequests, "k2": http.StatusTooManyRequests},
+			wantKeys: []string{"k1", "k2"},
+			wantErrs: []error{sources.ErrRateLimited},
+		},
+		{
+			name:     "every key rejected",
+			keys:     []string{"k1"},
+			statuses: map[string]int{"k1": http.StatusUnauthorized},
+			wantKeys: []string{"k1"},
+			wantErrs: []error{sources.ErrAuthFailed, sources.ErrQuotaExhausted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &server{t: t, handler: func(w http.ResponseWriter, r *http.Request, key string) {
+				if status, ok := tt.statuses[key]; ok {
+					w.WriteHeader(status)
+
+					return
+				}
+
+				switch r.URL.Query().Get("cursor") {
+				case "":
+					w.Write([]byte(`{"data":[{"attributes":{"url":"https://example.com/a"}}],"meta":{"cursor":"c2"}}`))
+				case "c2":
+					w.Write([]byte(`{"data":[{"attributes":{"url":"https://example.com/b"}}],"meta":{}}`))
+				}
+			}}
+
+			URLs, errs := s.run(t, &Source{}, tt.keys, tt.settings, false)
+
+			if !slices.Equal(URLs, tt.wantURLs) {
+				t.Errorf("Run() URLs = %v, want %v", URLs, tt.wantURLs)
+			}
+
+			if !slices.Equal(s.keys, tt.wantKeys) {
+				t.Errorf("request keys = %v, want %v", s.keys, tt.wantKeys)
+			}
+
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("Run() errors = %v, want %v", errs, tt.wantErrs)
+			}
+
+			for i, err := range errs {
+				if !errors.Is(err, tt.wantErrs[i]) {
+					t.Errorf("Run() error #%d = %v, want it to wrap %v", i, err, tt.wantErrs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSourceRunPremiumOnly(t *testing.T) {
+	t.Parallel()
+
+	s := &server{t: t, handler: func(w http.ResponseWriter, r *http.Request, key string) {
+		switch {
+		case r.URL.Path == "/api/v3/domains/example.com/urls" && key == "free":
+			w.WriteHeader(http.StatusForbidden)
+		case r.URL.Path == "/api/v3/domains/example.com/urls":
+			w.Write([]byte(`{"data":[{"attributes":{"url":"https://example.com/a"}}],"meta":{}}`))
+		case r.URL.Path == "/api/v3/domains/example.com/relationships/subdomains":
+			w.Write([]byte(`{"data":[],"meta":{}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}}
+
+	source := &Source{}
+
+	URLs, errs := s.run(t, source, []string{"free", "premium"}, nil, true)
+
+	if !slices.Equal(URLs, []string{"https://example.com/a"}) {
+		t.Errorf("Run() URLs = %v, want [https://example.com/a]", URLs)
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], errPremiumOnly) || !errors.Is(errs[0], sources.ErrAuthFailed) {
+		t.Errorf("Run() errors = %v, want a single error wrapping %v", errs, errPremiumOnly)
+	}
+
+	// The key is not set aside, so it remains usable for other endpoints.
+	if len(source.blocked) > 0 {
+		t.Errorf("blocked keys = %v, want none", source.blocked)
+	}
+
+	// Every key lacks access, which is not reported again, and nothing is listed.
+	s.requests = nil
+
+	URLs, errs = s.run(t, &Source{restricted: map[string]struct{}{"free": {}}}, []string{"free"}, nil, true)
+
+	if len(URLs) > 0 || len(errs) > 0 {
+		t.Errorf("Run() = %v, %v, want neither URLs nor errors", URLs, errs)
+	}
+
+	if len(s.requests) > 0 {
+		t.Errorf("requests = %v, want none", s.requests)
+	}
+}
+
+func TestSourceRunSubdomains(t *testing.T) {
+	t.Parallel()
+
+	s := &server{t: t, handler: func(w http.ResponseWriter, r *http.Request, _ string) {
+		switch r.URL.Path {
+		case "/api/v3/domains/example.com/urls":
+			w.Write([]byte(`{"data":[{"attributes":{"url":"https://example.com/a"}}],"meta":{}}`))
+		case "/api/v3/domains/example.com/relationships/subdomains":
+			w.Write([]byte(`{"data":[{"id":"a.example.com"},{"id":"b.example.com"}],"meta":{"cursor":"c2"}}`))
+		case "/api/v3/domains/a.example.com/urls":
+			// The last key is rejected, so listing stops.
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}}
+
+	URLs, errs := s.run(t, &Source{}, []string{"k1"}, nil, true)
+
+	if !slices.Equal(URLs, []string{"https://example.com/a"}) {
+		t.Errorf("Run() URLs = %v, want [https://example.com/a]", URLs)
+	}
+
+	// Stopping is not mistaken for a malformed page.
+	wantErrs := []error{sources.ErrAuthFailed, sources.ErrQuotaExhausted}
+
+	if len(errs) != len(wantErrs) {
+		t.Fatalf("Run() errors = %v, want %v", errs, wantErrs)
+	}
+
+	for i, err := range errs {
+		if !errors.Is(err, wantErrs[i]) {
+			t.Errorf("Run() error #%d = %v, want it to wrap %v", i, err, wantErrs[i])
+		}
+	}
+
+	wantRequests := []string{
+		"/api/v3/domains/example.com/urls",
+		"/api/v3/domains/example.com/relationships/subdomains",
+		"/api/v3/domains/a.example.com/urls",
+	}
+
+	if !slices.Equal(s.requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", s.requests, wantRequests)
+	}
+}
+
+func TestSourceRunV2(t *testing.T) {
+	t.Parallel()
+
+	s := &server{t: t, handler: func(w http.ResponseWriter, r *http.Request, key string) {
+		if r.Header.Get("x-apikey") != "" {
+			t.Error("v2 request carries an x-apikey header")
+		}
+
+		if got := r.URL.Query().Get("domain"); got != "example.com" {
+			t.Errorf("request domain = %s, want example.com", got)
+		}
+
+		// The v2 API answers with an empty 204 once a key is rate limited.
+		if key == "k1" {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		w.Write([]byte(`{"detected_urls":[{"url":"https://example.com/a","scan_date":"2024-01-02 03:04:05"}],"undetected_urls":[["https://example.com/b","id",0,70,"2024-01-02 03:04:05"]]}`))
+	}}
+
+	URLs, errs := s.run(t, &Source{}, []string{"k1", "k2"}, sources.Settings{"api": apiV2}, false)
+
+	if len(errs) > 0 {
+		t.Errorf("Run() errors = %v, want none", errs)
+	}
+
+	if !slices.Equal(URLs, []string{"https://example.com/a", "https://example.com/b"}) {
+		t.Errorf("Run() URLs = %v, want [https://example.com/a https://example.com/b]", URLs)
+	}
+
+	if !slices.Equal(s.keys, []string{"k1", "k2"}) {
+		t.Errorf("request keys = %v, want [k1 k2]", s.keys)
+	}
+}
@@ -0,0 +1,79 @@
+package wayback
+
+import (
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+// history aggregates the captures of every URL listed, in order of first listing.
+//
+// Fields:
+//   - order ([]string): The URLs, in order of first listing.
+//   - captures (map[string]*sources.Metadata): The aggregated metadata of every URL.
+type history struct {
+	order    []string
+	captures map[string]*sources.Metadata
+}
+
+// add records a capture of URL. The URL's first and last capture timestamps are widened to
+// include it, its capture count incremented, and its status code, MIME type and digest are
+// those of its latest capture.
+//
+// Parameters:
+//   - URL (string): The captured URL.
+//   - capture (*sources.Metadata): The metadata of the capture.
+func (h *history) add(URL string, capture *sources.Metadata) {
+	aggregated, ok := h.captures[URL]
+	if !ok {
+		copied := *capture
+
+		copied.Captures = 1
+
+		h.captures[URL] = &copied
+		h.order = append(h.order, URL)
+
+		return
+	}
+
+	aggregated.Captures++
+
+	if capture.FirstSeen.Before(aggregated.FirstSeen) {
+		aggregated.FirstSeen = capture.FirstSeen
+	}
+
+	if !capture.LastSeen.Before(aggregated.LastSeen) {
+		aggregated.LastSeen = capture.LastSeen
+		aggregated.StatusCode = capture.StatusCode
+		aggregated.MIMEType = capture.MIMEType
+		aggregated.Digest = capture.Digest
+	}
+}
+
+// dead reports whether URL was last captured before cutoff.
+//
+// Parameters:
+//   - URL (string): The URL.
+//   - cutoff (time.Time): The cutoff.
+//
+// Returns:
+//   - isDead (bool): Whether URL's last capture is older than cutoff.
+func (h *history) dead(URL string, cutoff time.Time) (isDead bool) {
+	aggregated, ok := h.captures[URL]
+
+	isDead = ok && !aggregated.LastSeen.IsZero() && aggregated.LastSeen.Before(cutoff)
+
+	return
+}
+
+// newHistory creates an empty history.
+//
+// Returns:
+//   - h (*history): The new history.
+func newHistory() (h *history) {
+	h = &history{
+		captures: map[string]*sources.Metadata{},
+	}
+
+	return
+}
@@ -0,0 +1,126 @@
+package wayback
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+func TestHistoryAdd(t *testing.T) {
+	t.Parallel()
+
+	capture := func(year, status int, digest string) *sources.Metadata {
+		seen := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		return &sources.Metadata{FirstSeen: seen, LastSeen: seen, StatusCode: status, MIMEType: "text/html", Digest: digest}
+	}
+
+	tests := []struct {
+		name     string
+		captures []*sources.Metadata
+		want     sources.Metadata
+	}{
+		{
+			name:     "single capture",
+			captures: []*sources.Metadata{capture(2020, 200, "A")},
+			want:     *withCaptures(capture(2020, 200, "A"), 1),
+		},
+		{
+			name:     "captures in order",
+			captures: []*sources.Metadata{capture(2020, 200, "A"), capture(2021, 301, "B"), capture(2023, 404, "C")},
+			want: sources.Metadata{
+				FirstSeen:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				LastSeen:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				StatusCode: 404,
+				MIMEType:   "text/html",
+				Digest:     "C",
+				Captures:   3,
+			},
+		},
+		{
+			name:     "captures out of order keep the latest capture's details",
+			captures: []*sources.Metadata{capture(2022, 404, "C"), capture(2019, 200, "A"), capture(2021, 301, "B")},
+			want: sources.Metadata{
+				FirstSeen:  time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+				LastSeen:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				StatusCode: 404,
+				MIMEType:   "text/html",
+				Digest:     "C",
+				Captures:   3,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := newHistory()
+
+			for _, c := range tt.captures {
+				h.add("https://example.com/", c)
+			}
+
+			if got := *h.captures["https://example.com/"]; got != tt.want {
+				t.Errorf("add() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryOrderAndCopies(t *testing.T) {
+	t.Parallel()
+
+	h := newHistory()
+
+	first := &sources.Metadata{StatusCode: 200}
+
+	h.add("https://example.com/b", first)
+	h.add("https://example.com/a", &sources.Metadata{})
+	h.add("https://example.com/b", &sources.Metadata{})
+
+	if !slices.Equal(h.order, []string{"https://example.com/b", "https://example.com/a"}) {
+		t.Errorf("order = %v, want b then a", h.order)
+	}
+
+	if first.Captures != 0 {
+		t.Errorf("add() modified its argument, captures = %d, want 0", first.Captures)
+	}
+}
+
+func TestHistoryDead(t *testing.T) {
+	t.Parallel()
+
+	cutoff := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	h := newHistory()
+
+	h.add("https://example.com/old", &sources.Metadata{LastSeen: cutoff.AddDate(-1, 0, 0)})
+	h.add("https://example.com/new", &sources.Metadata{LastSeen: cutoff.AddDate(1, 0, 0)})
+	h.add("https://example.com/unknown", &sources.Metadata{})
+
+	tests := []struct {
+		URL  string
+		want bool
+	}{
+		{URL: "https://example.com/old", want: true},
+		{URL: "https://example.com/new", want: false},
+		{URL: "https://example.com/unknown", want: false},
+		{URL: "https://example.com/missing", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := h.dead(tt.URL, cutoff); got != tt.want {
+			t.Errorf("dead(%s) = %v, want %v", tt.URL, got, tt.want)
+		}
+	}
+}
+
+// withCaptures sets the capture count of metadata.
+func withCaptures(metadata *sources.Metadata, captures int) *sources.Metadata {
+	metadata.Captures = captures
+
+	return metadata
+}
@@ -1,7 +1,7 @@
 // Package wayback provides an implementation of the sources.Source interface
 // for interacting with the Wayback Machine API.
 //
-// The Wayback Machine API (via Common Crawl's CDX server) allows retrieving historical
+// The Wayback Machine API (via its CDX server) allows retrieving historical
 // snapshots of URLs for a given domain. This package defines a Source type that implements
 // the Run and Name methods as specified by the sources.Source interface. The Run method
 // queries the Wayback Machine API for URL snapshots matching a target domain, validates
@@ -9,22 +9,39 @@
 package wayback
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
 
-	hqgohttp "github.com/hueristiq/hq-go-http"
-	hqgolimiter "github.com/hueristiq/hq-go-limiter"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
 	"github.com/spf13/cast"
 )
 
-// Source represents the Common Crawl data source implementation.
+// Source represents the Wayback Machine data source implementation.
 // It implements the sources.Source interface, providing functionality
 // for retrieving URLs from the Wayback Machine API.
 type Source struct{}
 
 // Run initiates the process of retrieving URL information from the Wayback Machine API for a given domain.
 //
+// Captures are listed page by page, up to the "limit" setting (5000 by default) per page, following the
+// resumption key the CDX server returns along with each page. The listing is narrowed server-side by the
+// "from" and "to" settings (timestamps, from a year, e.g., 2020, to a second, e.g., 20200615120000), the
+// "filters" setting (e.g., statuscode:200 or mimetype:text/html) and the "collapse" setting (urlkey by
+// default, i.e., one capture per URL).
+//
+// With the "history" setting, every capture is listed instead (unless "collapse" is set), and each
+// URL is reported once listing is over, with its number of captures and the timestamps of its first
+// and last capture. The "dead_after_days" setting implies "history", and only reports URLs whose last
+// capture is older than that many days (i.e., endpoints that may have disappeared). If the source
+// times out, the URLs of the captures listed so far are reported, with the history seen until then.
+//
 // Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the retrieval.
 //   - domain (string): The target domain for which URLs are to be retrieved.
 //   - cfg (*sources.Configuration): The configuration instance containing API keys,
 //     the URL validation function, and any additional settings required by the source.
@@ -33,28 +50,100 @@ type Source struct{}
 //   - (<-chan sources.Result): A channel that asynchronously emits sources.Result values.
 //     Each result is either a discovered URL (ResultURL) or an error (ResultError)
 //     encountered during the operation.
-func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sources.Result {
+func (source *Source) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
 	results := make(chan sources.Result)
 
 	go func() {
 		defer close(results)
 
-		for page := uint(0); ; page++ {
-			getURLsReqURL := "https://web.archive.org/cdx/search/cdx"
-			getURLsReqCFG := &hqgohttp.RequestConfiguration{
-				Params: map[string]string{
-					"url":      "*." + domain + "/*",
-					"output":   "json",
-					"collapse": "urlkey",
-					"fl":       "timestamp,original,mimetype,statuscode,digest",
-					"pageSize": "100",
-					"page":     cast.ToString(page),
-				},
+		settings := cfg.Settings[source.Name()]
+
+		deadAfterDays := settings.Int("dead_after_days", 0)
+
+		var captures *history
+
+		if settings.Bool("history", false) || deadAfterDays > 0 {
+			captures = newHistory()
+
+			defer func() {
+				// On timeout, the captures listed so far are still worth reporting; only
+				// cancellation means no one is interested anymore.
+				if errors.Is(ctx.Err(), context.Canceled) {
+					return
+				}
+
+				cutoff := time.Now().AddDate(0, 0, -deadAfterDays)
+
+				for _, URL := range captures.order {
+					if deadAfterDays > 0 && !captures.dead(URL, cutoff) {
+						continue
+					}
+
+					result := sources.Result{
+						Type:     sources.ResultURL,
+						Source:   source.Name(),
+						Value:    URL,
+						Metadata: captures.captures[URL],
+					}
+
+					results <- result
+				}
+			}()
+		}
+
+		collapse := []string{"urlkey"}
+
+		if captures != nil {
+			// Every capture of a URL is needed to tell its history.
+			collapse = nil
+		}
+
+		collapse = settings.Strings("collapse", collapse)
+
+		query := url.Values{}
+
+		query.Set("url", "*."+domain+"/*")
+		query.Set("output", "json")
+		query.Set("fl", "timestamp,original,mimetype,statuscode,digest")
+		query.Set("showResumeKey", "true")
+		query.Set("limit", strconv.Itoa(max(settings.Int("limit", defaultLimit), 1)))
+
+		if from := settings.String("from", ""); from != "" {
+			query.Set("from", from)
+		}
+
+		if to := settings.String("to", ""); to != "" {
+			query.Set("to", to)
+		}
+
+		for _, filter := range settings.Strings("filters", nil) {
+			query.Add("filter", filter)
+		}
+
+		for _, field := range collapse {
+			query.Add("collapse", field)
+		}
+
+		// With one capture per URL, a capture's timestamp is the URL's earliest.
+		earliest := slices.Equal(collapse, []string{"urlkey"})
+
+		resumeKey := ""
+
+		for {
+			if ctx.Err() != nil {
+				return
 			}
 
-			limiter.Wait()
+			getURLsReqURL := "https://web.archive.org/cdx/search/cdx?" + query.Encode()
+			getURLsReqCFG := &sources.RequestConfiguration{
+				Params: map[string]string{},
+			}
 
-			getURLsRes, err := hqgohttp.Get(getURLsReqURL, getURLsReqCFG)
+			if resumeKey != "" {
+				getURLsReqCFG.Params["resumeKey"] = resumeKey
+			}
+
+			getURLsRes, err := cfg.HTTPClient.Get(ctx, getURLsReqURL, getURLsReqCFG)
 			if err != nil {
 				result := sources.Result{
 					Type:   sources.ResultError,
@@ -64,7 +153,7 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 
 				results <- result
 
-				break
+				return
 			}
 
 			var getURLsResData [][]string
@@ -73,26 +162,43 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 				result := sources.Result{
 					Type:   sources.ResultError,
 					Source: source.Name(),
-					Error:  err,
+					Error:  sources.NewError(source.Name(), sources.ErrorKindMalformedResponse, getURLsRes, err),
 				}
 
 				results <- result
 
 				getURLsRes.Body.Close()
 
-				break
+				return
 			}
 
 			getURLsRes.Body.Close()
 
-			// check if there's results, wayback's pagination response
-			// is not always correct when using a filter
+			cfg.Statistics.PageFetched()
+
 			if len(getURLsResData) == 0 {
-				break
+				return
 			}
 
-			// Slicing as [1:] to skip first result by default
-			for _, record := range getURLsResData[1:] {
+			resumeKey = ""
+
+			// Slicing as [1:] to skip the header row. Records are followed by an empty
+			// row and the resumption key when there are more.
+			records := getURLsResData[1:]
+
+			for i, record := range records {
+				if len(record) == 0 {
+					if i+1 < len(records) && len(records[i+1]) > 0 {
+						resumeKey = records[i+1][0]
+					}
+
+					break
+				}
+
+				if len(record) < 5 {
+					continue
+				}
+
 				var URL string
 
 				var valid bool
@@ -101,20 +207,59 @@ func (source *Source) Run(domain string, cfg *sources.Configuration) <-chan sour
 					continue
 				}
 
+				if captures != nil {
+					captures.add(URL, parseMetadata(record, false))
+
+					continue
+				}
+
 				result := sources.Result{
-					Type:   sources.ResultURL,
-					Source: source.Name(),
-					Value:  URL,
+					Type:     sources.ResultURL,
+					Source:   source.Name(),
+					Value:    URL,
+					Metadata: parseMetadata(record, earliest),
 				}
 
 				results <- result
 			}
+
+			if resumeKey == "" {
+				return
+			}
 		}
 	}()
 
 	return results
 }
 
+// parseMetadata builds result metadata from a CDX record whose fields are, in order,
+// timestamp, original, mimetype, statuscode and digest.
+//
+// Parameters:
+//   - record ([]string): The CDX record.
+//   - earliest (bool): Whether the record's timestamp is the URL's earliest capture (i.e., the
+//     query collapses on the URL key), rather than a capture among others.
+//
+// Returns:
+//   - metadata (*sources.Metadata): The metadata extracted from the record.
+func parseMetadata(record []string, earliest bool) (metadata *sources.Metadata) {
+	metadata = &sources.Metadata{
+		StatusCode: cast.ToInt(record[3]),
+		MIMEType:   record[2],
+		Digest:     record[4],
+	}
+
+	if timestamp, err := time.Parse(timestampLayout, record[0]); err == nil {
+		metadata.FirstSeen = timestamp
+
+		if !earliest {
+			metadata.LastSeen = timestamp
+		}
+	}
+
+	return
+}
+
 // Name returns the unique identifier for the data source.
 // This identifier is used for logging, debugging, and associating results with the correct data source.
 //
@@ -124,10 +269,16 @@ func (source *Source) Name() (name string) {
 	return sources.WAYBACK
 }
 
-// limiter is a rate limiter instance configured to control the number of requests
-// sent to the Wayback Machine API. It ensures that no more than 40 requests are made per minute,
-// with a minimum delay of 30 seconds between requests.
-var limiter = hqgolimiter.New(&hqgolimiter.Configuration{
-	RequestsPerMinute:     40,
-	MinimumDelayInSeconds: 30,
-})
+// init registers the source, making it available to the Finder.
+func init() {
+	sources.Register(sources.WAYBACK, func() sources.Source {
+		return &Source{}
+	}, sources.KeyUnused, 40)
+}
+
+const (
+	// timestampLayout is the layout of the timestamps returned by the Wayback Machine CDX API.
+	timestampLayout = "20060102150405"
+	// defaultLimit is the default maximum number of captures listed per page.
+	defaultLimit = 5000
+)
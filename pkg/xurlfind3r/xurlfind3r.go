@@ -10,25 +10,31 @@
 package xurlfind3r
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
-	hqgohttp "github.com/hueristiq/hq-go-http"
 	hqgohttpheader "github.com/hueristiq/hq-go-http/header"
 	hqgourlextractor "github.com/hueristiq/hq-go-url/extractor"
 	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
-	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/bevigil"
-	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/commoncrawl"
-	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/github"
-	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/hudsonrock"
-	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/intelx"
-	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/otx"
-	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/urlscan"
-	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/virustotal"
-	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/wayback"
+
+	// Built-in sources register themselves on import.
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/bevigil"
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/commoncrawl"
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/github"
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/gitlab"
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/hudsonrock"
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/intelx"
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/otx"
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/sourcegraph"
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/urlscan"
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/virustotal"
+	_ "github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources/wayback"
 )
 
 // Finder is the primary structure for performing URL discovery.
@@ -37,28 +43,95 @@ import (
 // Fields:
 //   - sources (map[string]sources.Source): A map of string keys to sources.Source interfaces representing the enabled enumeration sources.
 //   - configuration (*sources.Configuration): A pointer to the sources.Configuration struct containing API keys and other settings.
+//   - timeout (time.Duration): The maximum duration of a whole scan, zero meaning no limit.
+//   - sourceTimeouts (map[string]time.Duration): The maximum duration of each source's part of a scan, keyed by source name.
+//   - aggregate (bool): Whether to emit one consolidated result per URL, with provenance, at the end of a scan.
+//   - limiters (map[string]*sources.Limiter): The limiter pacing each rate limited source's requests, keyed by
+//     source name. Limiters are shared by every scan run by the Finder.
+//   - skipped ([]string): The names of the sources left out for requiring keys none of which are configured.
 type Finder struct {
-	sources       map[string]sources.Source
-	configuration *sources.Configuration
+	sources        map[string]sources.Source
+	configuration  *sources.Configuration
+	timeout        time.Duration
+	sourceTimeouts map[string]time.Duration
+	aggregate      bool
+	limiters       map[string]*sources.Limiter
+	skipped        []string
+}
+
+// Skipped returns the names of the sources the Finder left out because they require keys, none
+// of which are configured, and were not explicitly selected.
+//
+// Returns:
+//   - names ([]string): The names of the skipped sources.
+func (finder *Finder) Skipped() (names []string) {
+	return finder.skipped
 }
 
 // Find initiates the URL discovery process for a specific domain.
+// It is a shorthand for FindWithContext using a background context, i.e., the
+// discovery runs until every enabled source is exhausted.
+//
+// Parameters:
+//   - domain (string): The target domain for URL discovery.
+//
+// Returns:
+//   - results (chan sources.Result): A channel that streams URL enumeration results.
+func (finder *Finder) Find(domain string) (results chan sources.Result) {
+	return finder.FindWithContext(context.Background(), domain)
+}
+
+// FindWithContext initiates the URL discovery process for a specific domain, bound to ctx.
 // It normalizes the domain name, applies source-specific logic, and streams results via a channel.
 // The method uses all enabled sources concurrently and aggregates their results.
 //
+// When ctx is cancelled or its deadline (or the configured scan timeout) is exceeded, in-flight
+// requests are aborted, a final ResultError carrying the context's error is emitted and the channel
+// is closed. A source that exceeds its own timeout is cut off the same way, with a ResultError
+// wrapping ErrTimedOut, while other sources keep delivering. Results found before the scan's or a
+// source's own timeout are still delivered, however slowly they are read; only once ctx is done are
+// the remaining source results drained and discarded.
+//
+// By default, each URL is emitted once, attributed to whichever source reported it first. When
+// provenance aggregation is enabled, URL results are instead held until every source is done and
+// then emitted once per URL with their Provenance set, listing every source that reported them.
+// Consolidated results are still emitted after the scan's own timeout, but not once ctx is done.
+//
 // Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the discovery.
 //   - domain (string): The target domain for URL discovery.
 //
 // Returns:
 //   - results (chan sources.Result): A channel that streams URL enumeration results.
-func (finder *Finder) Find(domain string) (results chan sources.Result) {
-	results = make(chan sources.Result)
+func (finder *Finder) FindWithContext(ctx context.Context, domain string) (results chan sources.Result) {
+	results, _ = finder.FindWithStatistics(ctx, domain)
+
+	return
+}
+
+// FindWithStatistics behaves like FindWithContext, and additionally returns the statistics of the scan.
+// The statistics' counters are updated while the scan runs; they, along with the elapsed times, are
+// final once the results channel is closed.
+//
+// Parameters:
+//   - ctx (context.Context): The context that bounds the lifetime of the discovery.
+//   - domain (string): The target domain for URL discovery.
+//
+// Returns:
+//   - results (chan sources.Result): A channel that streams URL enumeration results.
+//   - statistics (*Statistics): The statistics of the scan.
+func (finder *Finder) FindWithStatistics(ctx context.Context, domain string) (results chan sources.Result, statistics *Statistics) {
+	// Buffered so that the final cancellation result can always be delivered, even to a
+	// consumer that has stopped reading, once ctx is done.
+	results = make(chan sources.Result, 1)
 
-	finder.configuration.Extractor = hqgourlextractor.New(
+	cfg := *finder.configuration
+
+	cfg.Extractor = hqgourlextractor.New(
 		hqgourlextractor.WithHostPattern(`(?:(?:\w+[.])*` + regexp.QuoteMeta(domain) + hqgourlextractor.ExtractorPortOptionalPattern + `)`),
 	).CompileRegex()
 
-	finder.configuration.Validate = func(target string) (URL string, valid bool) {
+	cfg.Validate = func(target string) (URL string, valid bool) {
 		scheme := "https"
 
 		switch {
@@ -74,7 +147,7 @@ func (finder *Finder) Find(domain string) (results chan sources.Result) {
 
 		pattern := fmt.Sprintf(`https?://(www\.)?%s(:\d+)?(?:/[^?\s#]*)?(?:\?[^#\s]*)?(?:#[^\s]*)?`, regexp.QuoteMeta(domain))
 
-		if finder.configuration.IncludeSubdomains {
+		if cfg.IncludeSubdomains {
 			pattern = fmt.Sprintf(`https?://([a-z0-9-]+\.)*%s(:\d+)?(?:/[^?\s#]*)?(?:\?[^#\s]*)?(?:#[^\s]*)?`, regexp.QuoteMeta(domain))
 		}
 
@@ -83,40 +156,207 @@ func (finder *Finder) Find(domain string) (results chan sources.Result) {
 		return
 	}
 
+	statistics = &Statistics{
+		Domain:  domain,
+		Sources: map[string]*sources.Statistics{},
+	}
+
+	for name := range finder.sources {
+		statistics.Sources[name] = &sources.Statistics{}
+	}
+
+	started := time.Now()
+
+	parent := ctx
+
+	var cancel context.CancelFunc
+
+	if finder.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, finder.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
 	go func() {
 		defer close(results)
 
+		defer cancel()
+
 		seenURLs := &sync.Map{}
 
+		aggregated := newAggregator()
+
 		wg := &sync.WaitGroup{}
 
 		for name := range finder.sources {
 			wg.Add(1)
 
-			go func(source sources.Source) {
+			go func(source sources.Source, sStatistics *sources.Statistics) {
 				defer wg.Done()
 
-				sResults := source.Run(domain, finder.configuration)
+				sStarted := time.Now()
+
+				sCfg := cfg
+
+				sCfg.HTTPClient = cfg.HTTPClient.WithSource(name).WithStatistics(sStatistics).WithLimiter(finder.limiters[name])
+				sCfg.Statistics = sStatistics
+				sCfg.Validate = func(target string) (URL string, valid bool) {
+					URL, valid = cfg.Validate(target)
+					if !valid {
+						sStatistics.Rejected.Add(1)
+					}
+
+					return
+				}
+
+				sCtx := ctx
+
+				timeout, ok := finder.sourceTimeouts[source.Name()]
+				if ok && timeout > 0 {
+					var sCancel context.CancelFunc
+
+					sCtx, sCancel = context.WithTimeout(ctx, timeout)
+
+					defer sCancel()
+				}
+
+				sResults := source.Run(sCtx, domain, &sCfg)
 
 				for sResult := range sResults {
+					// Keep draining so the source can observe cancellation and exit.
+					if parent.Err() != nil {
+						continue
+					}
+
+					// The source's own report of its context ending duplicates the timeout or
+					// stop error reported below, once for each source and once for the scan.
+					if sResult.Type == sources.ResultError && sCtx.Err() != nil &&
+						(errors.Is(sResult.Error, context.DeadlineExceeded) || errors.Is(sResult.Error, context.Canceled)) {
+						continue
+					}
+
+					if sResult.Type == sources.ResultError {
+						sStatistics.Errors.Add(1)
+					}
+
+					if sResult.Type == sources.ResultURL && finder.aggregate {
+						if aggregated.Add(sResult) {
+							sStatistics.URLs.Add(1)
+						} else {
+							sStatistics.Duplicates.Add(1)
+						}
+
+						continue
+					}
+
 					if sResult.Type == sources.ResultURL {
 						_, loaded := seenURLs.LoadOrStore(sResult.Value, struct{}{})
 						if loaded {
+							sStatistics.Duplicates.Add(1)
+
 							continue
 						}
 					}
 
-					results <- sResult
+					if send(parent, results, sResult) && sResult.Type == sources.ResultURL {
+						sStatistics.URLs.Add(1)
+					}
 				}
-			}(finder.sources[name])
+
+				sStatistics.Elapsed = time.Since(sStarted)
+
+				if ctx.Err() == nil && errors.Is(sCtx.Err(), context.DeadlineExceeded) {
+					sStatistics.Errors.Add(1)
+
+					result := sources.Result{
+						Type:   sources.ResultError,
+						Source: source.Name(),
+						Error:  fmt.Errorf("%w after %s", ErrTimedOut, timeout),
+					}
+
+					send(parent, results, result)
+				}
+			}(finder.sources[name], statistics.Sources[name])
 		}
 
 		wg.Wait()
+
+		statistics.Elapsed = time.Since(started)
+
+		if finder.aggregate {
+			for _, result := range aggregated.Results() {
+				send(parent, results, result)
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			result := sources.Result{
+				Type:  sources.ResultError,
+				Error: fmt.Errorf("finding URLs for %s stopped: %w", domain, err),
+			}
+
+			if errors.Is(err, context.DeadlineExceeded) {
+				result.Error = fmt.Errorf("finding URLs for %s %w: %w", domain, ErrTimedOut, err)
+			}
+
+			select {
+			case results <- result:
+			case <-parent.Done():
+				// Pending results are discarded once ctx is done, and no one else sends anymore,
+				// so free the buffer for the final result, delivering it even to a consumer that
+				// has stopped reading.
+				select {
+				case <-results:
+				default:
+				}
+
+				results <- result
+			}
+		}
 	}()
 
 	return
 }
 
+// send delivers result on results, unless ctx is done first, in which case it is discarded.
+//
+// Parameters:
+//   - ctx (context.Context): The context whose end discards the result.
+//   - results (chan sources.Result): The channel to deliver the result on.
+//   - result (sources.Result): The result.
+//
+// Returns:
+//   - sent (bool): Whether the result was delivered.
+func send(ctx context.Context, results chan sources.Result, result sources.Result) (sent bool) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	select {
+	case results <- result:
+		sent = true
+	case <-ctx.Done():
+	}
+
+	return
+}
+
+// Statistics summarizes a scan of a domain.
+//
+// Fields:
+//   - Domain (string): The scanned domain.
+//   - Elapsed (time.Duration): The time the scan took to complete.
+//   - Sources (map[string]*sources.Statistics): The statistics of each enabled source, keyed by source name.
+type Statistics struct {
+	Domain  string
+	Elapsed time.Duration
+	Sources map[string]*sources.Statistics
+}
+
+// ErrTimedOut is the error wrapped by results emitted when a scan, or a single source
+// within it, exceeds its configured timeout.
+var ErrTimedOut = errors.New("timed out")
+
 type ClientConfiguration struct {
 	UserAgent string
 }
@@ -129,16 +369,35 @@ type ClientConfiguration struct {
 // - SourcesToUSe ([]string): List of source names to be used for enumeration.
 // - SourcesToExclude ([]string): List of source names to be excluded from enumeration.
 // - Keys (sources.Keys): API keys for authenticated sources.
+// - Timeout (time.Duration): The maximum duration of a scan, zero meaning no limit.
+// - SourceTimeouts (map[string]time.Duration): The maximum duration of each source within a scan,
+// keyed by source name (e.g., {"wayback": 10 * time.Minute}).
+// - AggregateProvenance (bool): Whether to emit one consolidated result per URL, listing every
+// source that reported it, instead of only the first.
+// - RateLimits (map[string]int): The maximum number of requests per minute of each source, keyed by
+// source name, overriding the defaults declared on registration. For sources that take keys, the limit
+// applies per key (e.g., {"virustotal": 4} with three keys allows 12 requests per minute). Zero means no limit.
+// - Settings (map[string]sources.Settings): Each source's own settings, keyed by source name
+// (e.g., {"github": {"max_pages": 20}}).
 type Configuration struct {
-	Client            *ClientConfiguration
-	IncludeSubdomains bool
-	SourcesToUse      []string
-	SourcesToExclude  []string
-	Keys              sources.Keys
+	Client              *ClientConfiguration
+	IncludeSubdomains   bool
+	SourcesToUse        []string
+	SourcesToExclude    []string
+	Keys                sources.Keys
+	Timeout             time.Duration
+	SourceTimeouts      map[string]time.Duration
+	AggregateProvenance bool
+	RateLimits          map[string]int
+	Settings            map[string]sources.Settings
 }
 
 // New initializes a new Finder instance with the specified configuration.
-// It sets up the enabled sources, applies exclusions, and configures the Finder.
+// It sets up the enabled sources from the sources registry, applies exclusions, and configures
+// the Finder. Unknown source names are ignored. When no sources are selected, every registered
+// source is used, except those that require keys none of which are configured (see Skipped);
+// explicitly selected sources are always used, reporting missing keys as ErrorKindMissingKey errors.
+// Each rate limited source gets a limiter of its own, scaled by its number of keys.
 //
 // Parameters:
 //   - cfg (*Configuration): The user-defined configuration for sources and API keys.
@@ -152,47 +411,62 @@ func New(cfg *Configuration) (finder *Finder, err error) {
 		configuration: &sources.Configuration{
 			IncludeSubdomains: cfg.IncludeSubdomains,
 			Keys:              cfg.Keys,
+			Settings:          cfg.Settings,
 		},
+		timeout:        cfg.Timeout,
+		sourceTimeouts: cfg.SourceTimeouts,
+		aggregate:      cfg.AggregateProvenance,
+		limiters:       map[string]*sources.Limiter{},
 	}
 
-	cc := hqgohttp.DefaultSprayingClientConfiguration
-
-	cc.Headers = []hqgohttp.Header{}
-	cc.Timeout = 1 * time.Hour
+	cc := &sources.HTTPClientConfiguration{
+		Timeout: 1 * time.Hour,
+		Headers: map[string]string{},
+	}
 
 	if cfg.Client != nil && cfg.Client.UserAgent != "" {
-		cc.Headers = append(cc.Headers, hqgohttp.NewSetHeader(hqgohttpheader.UserAgent.String(), cfg.Client.UserAgent))
+		cc.Headers[hqgohttpheader.UserAgent.String()] = cfg.Client.UserAgent
 	}
 
-	hqgohttp.DefaultClient, err = hqgohttp.NewClient(cc)
+	finder.configuration.HTTPClient, err = sources.NewHTTPClient(cc)
 	if err != nil {
 		return
 	}
 
-	if len(cfg.SourcesToUse) < 1 {
-		cfg.SourcesToUse = sources.List
+	selected := len(cfg.SourcesToUse) > 0
+
+	if !selected {
+		cfg.SourcesToUse = sources.Names()
 	}
 
 	for _, source := range cfg.SourcesToUse {
-		switch source {
-		case sources.BEVIGIL:
-			finder.sources[source] = &bevigil.Source{}
-		case sources.COMMONCRAWL:
-			finder.sources[source] = &commoncrawl.Source{}
-		case sources.GITHUB:
-			finder.sources[source] = &github.Source{}
-		case sources.HUDSONROCK:
-			finder.sources[source] = &hudsonrock.Source{}
-		case sources.INTELLIGENCEX:
-			finder.sources[source] = &intelx.Source{}
-		case sources.OPENTHREATEXCHANGE:
-			finder.sources[source] = &otx.Source{}
-		case sources.URLSCAN:
-			finder.sources[source] = &urlscan.Source{}
-		case sources.VIRUSTOTAL:
-			finder.sources[source] = &virustotal.Source{}
-		case sources.WAYBACK:
-			finder.sources[source] = &wayback.Source{}
+		registration, ok := sources.Lookup(source)
+		if !ok {
+			continue
+		}
+
+		if !selected && registration.Keys == sources.KeyRequired && len(cfg.Keys[source]) == 0 {
+			if !slices.Contains(cfg.SourcesToExclude, source) {
+				finder.skipped = append(finder.skipped, source)
+			}
+
+			continue
+		}
+
+		finder.sources[source] = registration.Factory()
+
+		rateLimit := registration.RateLimit
+
+		if override, ok := cfg.RateLimits[source]; ok {
+			rateLimit = override
+		}
+
+		if registration.Keys != sources.KeyUnused && len(cfg.Keys[source]) > 1 {
+			rateLimit *= len(cfg.Keys[source])
+		}
+
+		if limiter := sources.NewLimiter(rateLimit); limiter != nil {
+			finder.limiters[source] = limiter
 		}
 	}
 
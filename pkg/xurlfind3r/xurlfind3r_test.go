@@ -0,0 +1,195 @@
+package xurlfind3r_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r"
+	"github.com/hueristiq/xurlfind3r/pkg/xurlfind3r/sources"
+)
+
+// fake is a source that reports the number of URLs given by its "urls" setting and then, with
+// its "block" setting, blocks until its context is done, reporting the context's error as real
+// sources do.
+type fake struct{}
+
+func (source *fake) Run(ctx context.Context, domain string, cfg *sources.Configuration) <-chan sources.Result {
+	results := make(chan sources.Result)
+
+	go func() {
+		defer close(results)
+
+		settings := cfg.Settings[source.Name()]
+
+		for i := range settings.Int("urls", 0) {
+			result := sources.Result{
+				Type:   sources.ResultURL,
+				Source: source.Name(),
+				Value:  "https://" + domain + "/" + strconv.Itoa(i),
+			}
+
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if !settings.Bool("block", false) {
+			return
+		}
+
+		<-ctx.Done()
+
+		result := sources.Result{
+			Type:   sources.ResultError,
+			Source: source.Name(),
+			Error:  ctx.Err(),
+		}
+
+		results <- result
+	}()
+
+	return results
+}
+
+func (source *fake) Name() string {
+	return "fake"
+}
+
+func init() {
+	sources.Register("fake", func() sources.Source {
+		return &fake{}
+	}, sources.KeyUnused, 0)
+}
+
+// newFinder creates a Finder using only the fake source, with the given settings.
+func newFinder(t *testing.T, cfg *xurlfind3r.Configuration, settings sources.Settings) *xurlfind3r.Finder {
+	t.Helper()
+
+	cfg.SourcesToUse = []string{"fake"}
+	cfg.Settings = map[string]sources.Settings{"fake": settings}
+
+	finder, err := xurlfind3r.New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return finder
+}
+
+// collect reads every result, pausing for delay before each read.
+func collect(results chan sources.Result, delay time.Duration) (URLs []string, errs []error) {
+	for result := range results {
+		time.Sleep(delay)
+
+		switch result.Type {
+		case sources.ResultURL:
+			URLs = append(URLs, result.Value)
+		case sources.ResultError:
+			errs = append(errs, result.Error)
+		}
+	}
+
+	return
+}
+
+func TestFindWithContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	finder := newFinder(t, &xurlfind3r.Configuration{}, sources.Settings{"block": true})
+
+	for range 200 {
+		ctx, cancel := context.WithCancel(t.Context())
+
+		results := finder.FindWithContext(ctx, "example.com")
+
+		cancel()
+
+		_, errs := collect(results, 0)
+
+		if len(errs) == 0 || !errors.Is(errs[len(errs)-1], context.Canceled) {
+			t.Fatalf("FindWithContext() errors = %v, want the last one to wrap %v", errs, context.Canceled)
+		}
+	}
+}
+
+func TestFindWithContextCancelledWhileNotReading(t *testing.T) {
+	t.Parallel()
+
+	finder := newFinder(t, &xurlfind3r.Configuration{}, sources.Settings{"urls": 3, "block": true})
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	results := finder.FindWithContext(ctx, "example.com")
+
+	// Let the source fill the buffer while nothing is read.
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+
+	time.Sleep(50 * time.Millisecond)
+
+	_, errs := collect(results, 0)
+
+	if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("FindWithContext() errors = %v, want a single error wrapping %v", errs, context.Canceled)
+	}
+}
+
+func TestFindWithContextTimeout(t *testing.T) {
+	t.Parallel()
+
+	for _, aggregate := range []bool{false, true} {
+		t.Run("aggregate="+strconv.FormatBool(aggregate), func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &xurlfind3r.Configuration{
+				Timeout:             200 * time.Millisecond,
+				AggregateProvenance: aggregate,
+			}
+
+			finder := newFinder(t, cfg, sources.Settings{"urls": 3, "block": true})
+
+			// A slow but live consumer still gets every URL found before the deadline.
+			URLs, errs := collect(finder.FindWithContext(t.Context(), "example.com"), 100*time.Millisecond)
+
+			if len(URLs) != 3 {
+				t.Errorf("FindWithContext() URLs = %v, want 3", URLs)
+			}
+
+			if len(errs) == 0 || !errors.Is(errs[len(errs)-1], xurlfind3r.ErrTimedOut) {
+				t.Errorf("FindWithContext() errors = %v, want the last one to wrap %v", errs, xurlfind3r.ErrTimedOut)
+			}
+		})
+	}
+}
+
+func TestFindWithContextSourceTimeout(t *testing.T) {
+	t.Parallel()
+
+	cfg := &xurlfind3r.Configuration{
+		SourceTimeouts: map[string]time.Duration{"fake": 50 * time.Millisecond},
+	}
+
+	finder := newFinder(t, cfg, sources.Settings{"urls": 2, "block": true})
+
+	results, statistics := finder.FindWithStatistics(t.Context(), "example.com")
+
+	URLs, errs := collect(results, 0)
+
+	if len(URLs) != 2 {
+		t.Errorf("FindWithContext() URLs = %v, want 2", URLs)
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], xurlfind3r.ErrTimedOut) {
+		t.Errorf("FindWithContext() errors = %v, want a single error wrapping %v", errs, xurlfind3r.ErrTimedOut)
+	}
+
+	if got := statistics.Sources["fake"].Errors.Load(); got != 1 {
+		t.Errorf("statistics.Sources[\"fake\"].Errors = %d, want 1", got)
+	}
+}